@@ -13,6 +13,7 @@ import (
 //ProcessDriverFile execute an api resquest return the response
 func ProcessDriverFile(file *model.DriverFile, action, sourceFolder, outputFolder string, environments *model.Environments, restFunc util.Rest) model.Output {
 	var err error
+	restFunc = retryRest(restFunc, environments)
 	switch action {
 	case "r":
 		switch file.APIType() {
@@ -52,6 +53,22 @@ func ProcessDriverFile(file *model.DriverFile, action, sourceFolder, outputFolde
 	return model.Output{Code: constant.OutputSuccess, Debug: constant.Undefined}
 }
 
+//retryRest applies the retry policy of the environment that receives each request
+func retryRest(restFunc util.Rest, environments *model.Environments) util.Rest {
+	return func(jsonString []byte, config util.APIConfig, params map[string]string) ([]byte, int, error) {
+		env := environments.Target
+		if environments.Source != nil && environments.Source.URL != constant.Undefined && strings.HasPrefix(config.Endpoint, environments.Source.URL) {
+			if env == nil || !strings.HasPrefix(config.Endpoint, env.URL) {
+				env = environments.Source
+			}
+		}
+		if env == nil {
+			return restFunc(jsonString, config, params)
+		}
+		return env.SessionRest(env.Retry.Rest(restFunc, env.Name))(jsonString, config, params)
+	}
+}
+
 type result struct {
 	ID  int    `json:"_internalId"`
 	URL string `json:"_self"`
@@ -9,11 +9,14 @@ import (
 	"strings"
 
 	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/migration"
 	"github.com/andreluzz/cas-xog/model"
 	"github.com/andreluzz/cas-xog/util"
-	"github.com/tealeg/xlsx"
 )
 
+//restDateLayout is the format of the dates sent to the rest api
+const restDateLayout = "2006-01-02T15:04:05"
+
 type project struct {
 	ID    int              `json:"_internalId,omitempty"`
 	Code  string           `json:"code"`
@@ -50,9 +53,14 @@ type segment struct {
 }
 
 func migrateTask(file *model.DriverFile, outputFolder string, environments *model.Environments, restFunc util.Rest) error {
-	xlFile, err := xlsx.OpenFile(util.ReplacePathSeparatorByOS(file.ExcelFile))
+	source, err := migration.OpenDataSource(file)
+	if err != nil {
+		return fmt.Errorf("migration - %s", err.Error())
+	}
+	source.FormatDates(restDateLayout)
+	matches, err := source.Matches(file)
 	if err != nil {
-		return fmt.Errorf("migration - error opening excel. Debug: %s", err.Error())
+		return fmt.Errorf("migration - %s", err.Error())
 	}
 
 	excelStartRowIndex := 0
@@ -64,23 +72,14 @@ func migrateTask(file *model.DriverFile, outputFolder string, environments *mode
 		excelStartRowIndex--
 	}
 
-	projects := make(map[string]project, len(xlFile.Sheets[0].Rows))
+	projects := make(map[string]project, len(source.Rows))
 
-	for index, row := range xlFile.Sheets[0].Rows {
+	for index := range source.Rows {
 		if index >= excelStartRowIndex {
-			rowMap := make(map[string]string, len(file.MatchExcel))
-			for _, match := range file.MatchExcel {
-				index := match.Col - 1
-				if index >= len(row.Cells) {
-					break
-				}
-				cell := row.Cells[index]
-				if cell.IsTime() {
-					cellDate, _ := cell.GetTime(false)
-					fmt.Println("Date: ", cellDate.String())
-					rowMap[match.AttributeName] = cellDate.Format("YYYY-MM-DDTHH:MM:SS")
-				}
-				rowMap[match.AttributeName] = cell.String()
+			rowMap := make(map[string]string, len(matches))
+			values := source.Values(index, matches)
+			for i, match := range matches {
+				rowMap[match.AttributeName] = values[i]
 			}
 
 			p, ok := projects[rowMap["project"]]
@@ -151,6 +150,10 @@ func migrateTask(file *model.DriverFile, outputFolder string, environments *mode
 		}
 	}
 
+	if err := source.Err(); err != nil {
+		return fmt.Errorf("migration - %s", err.Error())
+	}
+
 	projectslice := []project{}
 	for _, value := range projects {
 		projectslice = append(projectslice, value)
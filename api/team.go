@@ -9,9 +9,9 @@ import (
 	"strconv"
 
 	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/migration"
 	"github.com/andreluzz/cas-xog/model"
 	"github.com/andreluzz/cas-xog/util"
-	"github.com/tealeg/xlsx"
 )
 
 type team struct {
@@ -35,9 +35,13 @@ type teamDefAllocationsResults struct {
 }
 
 func migrateTeam(file *model.DriverFile, outputFolder string, environments *model.Environments, restFunc util.Rest) error {
-	xlFile, err := xlsx.OpenFile(util.ReplacePathSeparatorByOS(file.ExcelFile))
+	source, err := migration.OpenDataSource(file)
 	if err != nil {
-		return fmt.Errorf("migration - error opening excel. Debug: %s", err.Error())
+		return fmt.Errorf("migration - %s", err.Error())
+	}
+	matches, err := source.Matches(file)
+	if err != nil {
+		return fmt.Errorf("migration - %s", err.Error())
 	}
 
 	excelStartRowIndex := 0
@@ -49,7 +53,7 @@ func migrateTeam(file *model.DriverFile, outputFolder string, environments *mode
 		excelStartRowIndex--
 	}
 
-	teams := make(map[string]team, len(xlFile.Sheets[0].Rows))
+	teams := make(map[string]team, len(source.Rows))
 
 	excelEndRowIndex := 0
 	if file.ExcelStartRow != constant.Undefined {
@@ -59,14 +63,15 @@ func migrateTeam(file *model.DriverFile, outputFolder string, environments *mode
 		}
 	}
 
-	for index, row := range xlFile.Sheets[0].Rows {
+	for index := range source.Rows {
 		if excelEndRowIndex != 0 && excelEndRowIndex == index {
 			break
 		}
 		if index >= excelStartRowIndex {
-			rowMap := make(map[string]string, len(file.MatchExcel))
-			for _, match := range file.MatchExcel {
-				rowMap[match.AttributeName] = row.Cells[match.Col-1].String()
+			rowMap := make(map[string]string, len(matches))
+			values := source.Values(index, matches)
+			for i, match := range matches {
+				rowMap[match.AttributeName] = values[i]
 			}
 
 			t, ok := teams[rowMap["code"]]
@@ -102,6 +107,10 @@ func migrateTeam(file *model.DriverFile, outputFolder string, environments *mode
 		}
 	}
 
+	if err := source.Err(); err != nil {
+		return fmt.Errorf("migration - %s", err.Error())
+	}
+
 	teamSlice := []team{}
 	for _, value := range teams {
 		teamSlice = append(teamSlice, value)
@@ -0,0 +1,148 @@
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//Journal records the driver files that were successfully processed, allowing an interrupted run to be resumed
+type Journal struct {
+	path      string
+	mutex     sync.Mutex
+	file      *os.File
+	completed map[string]bool
+}
+
+type record struct {
+	Key       string    `json:"key"`
+	Completed time.Time `json:"completed"`
+}
+
+//Path returns the path of the journal used by the driver and action
+func Path(driverPath, action string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.TrimSuffix(driverPath, filepath.Ext(driverPath)))
+	return constant.FolderCheckpoint + name + "_" + action + ".journal"
+}
+
+//Exists validates if there is a journal from a previous run of the driver and action
+func Exists(driverPath, action string) bool {
+	_, err := os.Stat(Path(driverPath, action))
+	return err == nil
+}
+
+//Open creates the journal of the driver and action. If resume is true the entries from the previous run are loaded, otherwise the journal starts empty
+func Open(driverPath, action string, resume bool) (*Journal, error) {
+	j := &Journal{
+		path:      Path(driverPath, action),
+		completed: make(map[string]bool),
+	}
+	util.ValidateFolder(filepath.Dir(j.path))
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		err := j.load()
+		if err != nil {
+			return nil, err
+		}
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(j.path, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint journal %s. Debug: %s", j.path, err.Error())
+	}
+	j.file = file
+	return j, nil
+}
+
+func (j *Journal) load() error {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading checkpoint journal %s. Debug: %s", j.path, err.Error())
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		r := record{}
+		//an incomplete line is expected if the process was killed while writing it
+		if json.Unmarshal(scanner.Bytes(), &r) == nil && r.Key != constant.Undefined {
+			j.completed[r.Key] = true
+		}
+	}
+	return scanner.Err()
+}
+
+//Key returns the identifier of a driver file entry. Split files are identified by their own path
+func Key(index int, xmlType, path string) string {
+	return fmt.Sprintf("%03d|%s|%s", index, xmlType, path)
+}
+
+//Completed validates if the entry was successfully processed in a previous run
+func (j *Journal) Completed(key string) bool {
+	if j == nil {
+		return false
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.completed[key]
+}
+
+//Total returns the number of entries already completed
+func (j *Journal) Total() int {
+	if j == nil {
+		return 0
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return len(j.completed)
+}
+
+//Register saves the entry as completed, writing it to disk immediately so it survives an interruption
+func (j *Journal) Register(key string) error {
+	if j == nil {
+		return nil
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	data, err := json.Marshal(record{Key: key, Completed: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = j.file.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint journal %s. Debug: %s", j.path, err.Error())
+	}
+	j.completed[key] = true
+	return j.file.Sync()
+}
+
+//Close closes the journal file keeping it to resume the run later
+func (j *Journal) Close() {
+	if j == nil {
+		return
+	}
+	j.file.Close()
+}
+
+//Remove closes and deletes the journal, used when the run has finished without errors
+func (j *Journal) Remove() {
+	if j == nil {
+		return
+	}
+	j.file.Close()
+	os.Remove(j.path)
+}
@@ -0,0 +1,76 @@
+package checkpoint
+
+import (
+	"os"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+)
+
+func TestJournalToResumeCompletedEntries(t *testing.T) {
+	defer os.RemoveAll(constant.FolderCheckpoint)
+
+	driverPath := "drivers/release.driver"
+	journal, err := Open(driverPath, constant.Write, false)
+	if err != nil {
+		t.Fatalf("Error opening journal. Debug: %s", err.Error())
+	}
+	journal.Register(Key(0, "object", "idea.xml"))
+	journal.Register(Key(1, "view", "views_001.xml"))
+	journal.Close()
+
+	if !Exists(driverPath, constant.Write) {
+		t.Fatalf("Error closing journal. Journal file %s not kept", Path(driverPath, constant.Write))
+	}
+
+	//simulates a process killed while writing an entry
+	file, _ := os.OpenFile(Path(driverPath, constant.Write), os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"key":"002|view|views_0`)
+	file.Close()
+
+	journal, err = Open(driverPath, constant.Write, true)
+	if err != nil {
+		t.Fatalf("Error resuming journal. Debug: %s", err.Error())
+	}
+	defer journal.Close()
+	if journal.Total() != 2 {
+		t.Errorf("Error resuming journal. Expected 2 completed entries received %d", journal.Total())
+	}
+	if !journal.Completed(Key(1, "view", "views_001.xml")) {
+		t.Errorf("Error resuming journal. Split file entry not completed")
+	}
+	if journal.Completed(Key(2, "view", "views_002.xml")) {
+		t.Errorf("Error resuming journal. Entry not registered was completed")
+	}
+}
+
+func TestJournalToStartEmptyWithoutResume(t *testing.T) {
+	defer os.RemoveAll(constant.FolderCheckpoint)
+
+	driverPath := "drivers/release.driver"
+	journal, _ := Open(driverPath, constant.Read, false)
+	journal.Register(Key(0, "object", "idea.xml"))
+	journal.Close()
+
+	journal, err := Open(driverPath, constant.Read, false)
+	if err != nil {
+		t.Fatalf("Error opening journal. Debug: %s", err.Error())
+	}
+	if journal.Total() != 0 || journal.Completed(Key(0, "object", "idea.xml")) {
+		t.Errorf("Error opening journal without resume. Entries from previous run loaded")
+	}
+	journal.Remove()
+
+	if Exists(driverPath, constant.Read) {
+		t.Errorf("Error removing journal. File %s still exists", Path(driverPath, constant.Read))
+	}
+}
+
+func TestJournalNilToBeIgnored(t *testing.T) {
+	var journal *Journal
+	if journal.Completed("key") || journal.Total() != 0 || journal.Register("key") != nil {
+		t.Errorf("Error using nil journal. Expected no entries and no errors")
+	}
+	journal.Close()
+	journal.Remove()
+}
@@ -0,0 +1,274 @@
+package compare
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+//Kinds of changes found between the source and the target. The changes describe the source compared to the target,
+//so an element added exists only in the source and an element removed exists only in the target
+const (
+	KindAdded   = "added"
+	KindRemoved = "removed"
+	KindChanged = "changed"
+	KindMoved   = "moved"
+)
+
+//Status of an artifact compared
+const (
+	StatusEqual     = "equal"
+	StatusDifferent = "different"
+	StatusError     = "error"
+	StatusIgnored   = "ignored"
+)
+
+//keyAttributes defines, in order of priority, the attributes that identify an element among its siblings
+var keyAttributes = []string{"code", "attributeCode", "languageCode", "lookupCode", "id", "sequence"}
+
+//ignoredTags are not compared because they change on every xog execution
+var ignoredTags = map[string]bool{"Header": true, "XOGOutput": true}
+
+//Change defines a single difference between the source and the target xog
+type Change struct {
+	Kind      string `json:"kind"`
+	Element   string `json:"element"`
+	Path      string `json:"path"`
+	Attribute string `json:"attribute,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Target    string `json:"target,omitempty"`
+}
+
+//Artifact defines the result of the comparison of one driver file
+type Artifact struct {
+	Type    string   `json:"type"`
+	Code    string   `json:"code"`
+	Path    string   `json:"path"`
+	Status  string   `json:"status"`
+	Debug   string   `json:"debug,omitempty"`
+	Changes []Change `json:"changes"`
+}
+
+//SetChanges defines the changes of the artifact and its status
+func (a *Artifact) SetChanges(changes []Change) {
+	a.Changes = changes
+	a.Status = StatusEqual
+	if len(changes) > 0 {
+		a.Status = StatusDifferent
+	}
+}
+
+//Summary returns the total of changes by kind
+func (a *Artifact) Summary() map[string]int {
+	summary := map[string]int{KindAdded: 0, KindRemoved: 0, KindChanged: 0, KindMoved: 0}
+	for _, c := range a.Changes {
+		summary[c.Kind]++
+	}
+	return summary
+}
+
+//unmatched is an element found only on one side, kept to detect elements moved to another parent
+type unmatched struct {
+	scope   string
+	local   string
+	keyed   bool
+	path    string
+	element *etree.Element
+}
+
+type differ struct {
+	changes []Change
+	added   []unmatched
+	removed []unmatched
+}
+
+//Diff compares the NikuDataBus of the source and the target xog and returns their differences.
+//Elements are matched by their identifying attributes, like code and attributeCode, or by their position when they have none
+func Diff(source, target *etree.Document) []Change {
+	d := &differ{changes: []Change{}}
+	s := dataBus(source)
+	t := dataBus(target)
+	switch {
+	case s == nil && t == nil:
+		return d.changes
+	case t == nil:
+		d.changes = append(d.changes, Change{Kind: KindAdded, Element: s.Tag, Path: s.Tag})
+		return d.changes
+	case s == nil:
+		d.changes = append(d.changes, Change{Kind: KindRemoved, Element: t.Tag, Path: t.Tag})
+		return d.changes
+	}
+
+	d.compareElements("", "", s, t)
+	d.matchMoved()
+	return d.changes
+}
+
+func dataBus(doc *etree.Document) *etree.Element {
+	if doc == nil {
+		return nil
+	}
+	return doc.FindElement("//NikuDataBus")
+}
+
+func (d *differ) compareElements(path, scope string, s, t *etree.Element) {
+	d.compareAttributes(path, s, t)
+
+	sText := strings.TrimSpace(s.Text())
+	tText := strings.TrimSpace(t.Text())
+	if sText != tText {
+		d.changes = append(d.changes, Change{Kind: KindChanged, Element: s.Tag, Path: path, Attribute: "text()", Source: sText, Target: tText})
+	}
+
+	sChildren, sKeys := children(s)
+	tChildren, tKeys := children(t)
+	for _, key := range sKeys {
+		childPath := joinPath(path, key)
+		childScope := scopeOf(scope, childPath, sChildren[key])
+		if tChild, ok := tChildren[key]; ok {
+			d.compareElements(childPath, childScope, sChildren[key], tChild)
+			continue
+		}
+		d.added = append(d.added, newUnmatched(scope, path, key, sChildren[key]))
+	}
+	for _, key := range tKeys {
+		if _, ok := sChildren[key]; !ok {
+			d.removed = append(d.removed, newUnmatched(scope, path, key, tChildren[key]))
+		}
+	}
+}
+
+func (d *differ) compareAttributes(path string, s, t *etree.Element) {
+	names := map[string]bool{}
+	for _, a := range s.Attr {
+		names[a.FullKey()] = true
+	}
+	for _, a := range t.Attr {
+		names[a.FullKey()] = true
+	}
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		sAttr := s.SelectAttr(name)
+		tAttr := t.SelectAttr(name)
+		switch {
+		case sAttr == nil:
+			d.changes = append(d.changes, Change{Kind: KindChanged, Element: s.Tag, Path: path, Attribute: name, Target: tAttr.Value})
+		case tAttr == nil:
+			d.changes = append(d.changes, Change{Kind: KindChanged, Element: s.Tag, Path: path, Attribute: name, Source: sAttr.Value})
+		case sAttr.Value != tAttr.Value:
+			d.changes = append(d.changes, Change{Kind: KindChanged, Element: s.Tag, Path: path, Attribute: name, Source: sAttr.Value, Target: tAttr.Value})
+		}
+	}
+}
+
+//matchMoved pairs the elements found only on one side that have the same identification inside the same scope, like a view field in another section
+func (d *differ) matchMoved() {
+	added, removed := d.added, d.removed
+	d.added, d.removed = nil, nil
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	removedByKey := map[string][]int{}
+	for i, r := range removed {
+		if r.keyed {
+			removedByKey[r.scope+"|"+r.local] = append(removedByKey[r.scope+"|"+r.local], i)
+		}
+	}
+	addedCount := map[string]int{}
+	for _, a := range added {
+		if a.keyed {
+			addedCount[a.scope+"|"+a.local]++
+		}
+	}
+
+	moved := map[int]bool{}
+	for _, a := range added {
+		key := a.scope + "|" + a.local
+		if !a.keyed || addedCount[key] != 1 || len(removedByKey[key]) != 1 {
+			d.changes = append(d.changes, Change{Kind: KindAdded, Element: a.element.Tag, Path: joinPath(a.path, a.local)})
+			continue
+		}
+		index := removedByKey[key][0]
+		r := removed[index]
+		moved[index] = true
+		d.changes = append(d.changes, Change{Kind: KindMoved, Element: a.element.Tag, Path: a.local, Source: a.path, Target: r.path})
+		d.compareElements(joinPath(a.path, a.local), a.scope, a.element, r.element)
+	}
+	for i, r := range removed {
+		if !moved[i] {
+			d.changes = append(d.changes, Change{Kind: KindRemoved, Element: r.element.Tag, Path: joinPath(r.path, r.local)})
+		}
+	}
+	//the children of the moved elements can also be unmatched
+	d.matchMoved()
+}
+
+func newUnmatched(scope, path, key string, e *etree.Element) unmatched {
+	return unmatched{scope: scope, local: key, keyed: strings.Contains(key, "="), path: path, element: e}
+}
+
+//children returns the child elements indexed by their key and the keys in document order
+func children(e *etree.Element) (map[string]*etree.Element, []string) {
+	elements := map[string]*etree.Element{}
+	keys := []string{}
+	positions := map[string]int{}
+	unkeyed := map[string]int{}
+	for _, c := range e.ChildElements() {
+		if elementKey(c) == "" {
+			unkeyed[c.Tag]++
+		}
+	}
+	for _, c := range e.ChildElements() {
+		if ignoredTags[c.Tag] {
+			continue
+		}
+		key := elementKey(c)
+		if key == "" {
+			positions[c.Tag]++
+			key = c.Tag
+			if unkeyed[c.Tag] > 1 {
+				key += "[" + strconv.Itoa(positions[c.Tag]) + "]"
+			}
+		}
+		if _, ok := elements[key]; ok {
+			positions[key]++
+			key = key + "[" + strconv.Itoa(positions[key]+1) + "]"
+		}
+		elements[key] = c
+		keys = append(keys, key)
+	}
+	return elements, keys
+}
+
+//elementKey returns the tag with the first identifying attribute defined in the element
+func elementKey(e *etree.Element) string {
+	for _, attr := range keyAttributes {
+		if value := e.SelectAttrValue(attr, ""); value != "" {
+			return e.Tag + "[" + attr + "=" + value + "]"
+		}
+	}
+	return ""
+}
+
+//scopeOf returns the path of the element if it has the attribute code, used to limit where a moved element is searched
+func scopeOf(scope, path string, e *etree.Element) string {
+	if e.SelectAttr("code") != nil {
+		return path
+	}
+	return scope
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "/" + key
+}
@@ -0,0 +1,112 @@
+package compare
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/beevik/etree"
+)
+
+var packageMockFolder string
+
+func init() {
+	packageMockFolder = "../" + constant.FolderMock + "compare/"
+}
+
+func readMock(t *testing.T, name string) *etree.Document {
+	doc := etree.NewDocument()
+	err := doc.ReadFromFile(packageMockFolder + name)
+	if err != nil {
+		t.Fatalf("Error reading mock %s. Debug: %s", name, err.Error())
+	}
+	return doc
+}
+
+func findChange(changes []Change, kind, path, attribute string) *Change {
+	for i, c := range changes {
+		if c.Kind == kind && c.Path == path && c.Attribute == attribute {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestDiffEqual(t *testing.T) {
+	changes := Diff(readMock(t, "lookup_source.xml"), readMock(t, "lookup_source.xml"))
+
+	if len(changes) != 0 {
+		t.Errorf("Error comparing xog. Expected no changes and received %v", changes)
+	}
+}
+
+func TestDiffLookupValues(t *testing.T) {
+	changes := Diff(readMock(t, "lookup_source.xml"), readMock(t, "lookup_target.xml"))
+
+	lookupPath := "contentPack/lookups/staticLookup[code=LOOKUP_CAS_XOG]/"
+	status := findChange(changes, KindChanged, lookupPath+"lookupValue[code=valor_it]", "status")
+	if status == nil || status.Source != "active" || status.Target != "inactive" {
+		t.Errorf("Error comparing xog. Expected status changed on valor_it and received %v", changes)
+	}
+	if findChange(changes, KindAdded, lookupPath+"lookupValue[code=valor_npd]", "") == nil {
+		t.Errorf("Error comparing xog. Expected valor_npd added and received %v", changes)
+	}
+	if findChange(changes, KindRemoved, lookupPath+"lookupValue[code=valor_prod]", "") == nil {
+		t.Errorf("Error comparing xog. Expected valor_prod removed and received %v", changes)
+	}
+	if len(changes) != 3 {
+		t.Errorf("Error comparing xog. Expected 3 changes and received %d: %v", len(changes), changes)
+	}
+}
+
+func TestDiffViewFieldMoved(t *testing.T) {
+	changes := Diff(readMock(t, "view_source.xml"), readMock(t, "view_target.xml"))
+
+	sectionPath := "contentPack/views/property[code=obj_sistemaCreate]/section[sequence=1]/"
+	moved := findChange(changes, KindMoved, "viewFieldDescriptor[attributeCode=status]", "")
+	if moved == nil || moved.Source != sectionPath+"column[sequence=1]" || moved.Target != sectionPath+"column[sequence=2]" {
+		t.Errorf("Error comparing xog. Expected field status moved and received %v", changes)
+	}
+	required := findChange(changes, KindChanged, sectionPath+"column[sequence=1]/viewFieldDescriptor[attributeCode=status]", "required")
+	if required == nil || required.Source != "false" || required.Target != "true" {
+		t.Errorf("Error comparing xog. Expected attribute required changed on moved field and received %v", changes)
+	}
+	nls := findChange(changes, KindChanged, sectionPath+"column[sequence=2]/viewFieldDescriptor[attributeCode=code]/nls[languageCode=en]", "name")
+	if nls == nil || nls.Source != "ID" || nls.Target != "Code" {
+		t.Errorf("Error comparing xog. Expected nls name changed and received %v", changes)
+	}
+	if len(changes) != 3 {
+		t.Errorf("Error comparing xog. Expected 3 changes and received %d: %v", len(changes), changes)
+	}
+}
+
+func TestReportSave(t *testing.T) {
+	r := NewReport("compare.driver", "DEV", "PROD")
+	artifact := &Artifact{Type: "lookup", Code: "LOOKUP_CAS_XOG", Path: "lookup.xml"}
+	artifact.SetChanges(Diff(readMock(t, "lookup_source.xml"), readMock(t, "lookup_target.xml")))
+	r.Add(artifact)
+	r.Add(&Artifact{Type: "api.team", Path: "team.json", Status: StatusIgnored, Changes: []Change{}})
+
+	path := "../" + constant.FolderCompare + "test_report"
+	err := r.Save(path)
+	if err != nil {
+		t.Fatalf("Error saving compare report. Debug: %s", err.Error())
+	}
+	defer os.RemoveAll("../" + constant.FolderCompare)
+
+	data, err := ioutil.ReadFile(path + ".json")
+	if err != nil {
+		t.Fatalf("Error reading json compare report. Debug: %s", err.Error())
+	}
+	saved := Report{}
+	json.Unmarshal(data, &saved)
+	if saved.Results[StatusDifferent] != 1 || saved.Results[StatusIgnored] != 1 || len(saved.Artifacts[0].Changes) != 3 {
+		t.Errorf("Error saving json compare report. Unexpected content %s", string(data))
+	}
+
+	if _, err := os.Stat(path + ".html"); err != nil {
+		t.Errorf("Error saving html compare report. Debug: %s", err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//Report defines the comparison of all artifacts of a driver between two environments
+type Report struct {
+	Driver    string         `json:"driver"`
+	Source    string         `json:"source"`
+	Target    string         `json:"target"`
+	Started   time.Time      `json:"started"`
+	Results   map[string]int `json:"results"`
+	Artifacts []*Artifact    `json:"artifacts"`
+}
+
+//NewReport creates an empty report to register the artifacts compared
+func NewReport(driver, source, target string) *Report {
+	return &Report{
+		Driver:    driver,
+		Source:    source,
+		Target:    target,
+		Started:   time.Now(),
+		Results:   map[string]int{StatusEqual: 0, StatusDifferent: 0, StatusError: 0, StatusIgnored: 0},
+		Artifacts: []*Artifact{},
+	}
+}
+
+//Add registers an artifact compared
+func (r *Report) Add(a *Artifact) {
+	r.Results[a.Status]++
+	r.Artifacts = append(r.Artifacts, a)
+}
+
+//Save writes the report as JSON and HTML using the path without extension
+func (r *Report) Save(path string) error {
+	util.ValidateFolder(filepath.Dir(path))
+
+	data, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+".json", data, 0644)
+	if err != nil {
+		return fmt.Errorf("error saving json compare report. Debug: %s", err.Error())
+	}
+
+	var html bytes.Buffer
+	err = htmlTemplate.Execute(&html, r)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+".html", html.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("error saving html compare report. Debug: %s", err.Error())
+	}
+	return nil
+}
+
+//DefaultPath returns the path, without extension, used to save a compare report created now
+func DefaultPath() string {
+	return constant.FolderCompare + "compare_" + time.Now().Format("20060102_150405")
+}
+
+var htmlTemplate = template.Must(template.New("compare").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>CAS-XOG compare - {{.Source}} x {{.Target}}</title>
+<style>
+body { font-family: Arial, sans-serif; font-size: 13px; margin: 20px; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 20px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+.equal { color: #2e7d32; } .different { color: #e65100; } .error { color: #c62828; } .ignored { color: #757575; }
+.added { background: #e8f5e9; } .removed { background: #ffebee; } .changed { background: #fff8e1; } .moved { background: #e3f2fd; }
+td.value { font-family: monospace; white-space: pre-wrap; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>Compare {{.Source}} x {{.Target}}</h1>
+<p>Driver: {{.Driver}} | Started: {{.Started.Format "2006-01-02 15:04:05"}} | Equal: {{index .Results "equal"}} | Different: {{index .Results "different"}} | Errors: {{index .Results "error"}} | Ignored: {{index .Results "ignored"}}</p>
+<p>The changes describe {{.Source}} compared to {{.Target}}: added elements exist only in {{.Source}} and removed elements exist only in {{.Target}}.</p>
+<table>
+<tr><th>Type</th><th>Code</th><th>Path</th><th>Status</th><th>Changes</th></tr>
+{{range .Artifacts}}<tr><td>{{.Type}}</td><td>{{.Code}}</td><td>{{.Path}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{len .Changes}}{{if .Debug}} - {{.Debug}}{{end}}</td></tr>
+{{end}}</table>
+{{$source := .Source}}{{$target := .Target}}
+{{range .Artifacts}}{{if .Changes}}
+<h2>{{.Type}} - {{.Path}}</h2>
+<table>
+<tr><th>Kind</th><th>Element</th><th>Path</th><th>Attribute</th><th>{{$source}}</th><th>{{$target}}</th></tr>
+{{range .Changes}}<tr class="{{.Kind}}"><td>{{.Kind}}</td><td>{{.Element}}</td><td class="value">{{.Path}}</td><td>{{.Attribute}}</td><td class="value">{{.Source}}</td><td class="value">{{.Target}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`))
@@ -42,19 +42,28 @@ const (
 	ActionInsert          = "insert"
 	ActionRemoveAllButNot = "removeAllButNot"
 
-	Read    = "r"
-	Write   = "w"
-	Migrate = "m"
-	Package = "p"
-	Load    = "l"
-	Exit    = "x"
+	Read     = "r"
+	Write    = "w"
+	Migrate  = "m"
+	Package  = "p"
+	Load     = "l"
+	Validate = "v"
+	Compare  = "c"
+	Build    = "k"
+	Rollback = "b"
+	Exit     = "x"
 
-	FolderRead      = "_read/"
-	FolderWrite     = "_write/"
-	FolderMigration = "_migration/"
-	FolderDebug     = "_debug/"
-	FolderPackage   = "_packages/"
-	FolderMock      = "mock/"
+	FolderRead       = "_read/"
+	FolderWrite      = "_write/"
+	FolderMigration  = "_migration/"
+	FolderDebug      = "_debug/"
+	FolderPackage    = "_packages/"
+	FolderMock       = "mock/"
+	FolderReport     = "_reports/"
+	FolderDryRun     = "_dryrun/"
+	FolderCheckpoint = "_checkpoint/"
+	FolderCompare    = "_compare/"
+	FolderBackup     = "_backup/"
 
 	Undefined     = ""
 	OutputError   = "error"
@@ -78,4 +87,9 @@ const (
 	Target = "target"
 
 	DefaultInstanceTag = "instance"
+
+	ExitCodeSuccess = 0
+	ExitCodeError   = 1
+	ExitCodeWarning = 2
+	ExitCodeUsage   = 3
 )
@@ -1,43 +1,229 @@
 package log
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/andreluzz/cas-xog/util"
 	"github.com/mattn/go-colorable"
 )
 
-var logger *log.Logger
+//Level defines the severity of a log record
+type Level int
+
+//Available log levels, from the most to the least severe
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+var levelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+//levelTitles are the names of the levels in the text records
+var levelTitles = []string{"Error", "Warn", "Info", "Debug", "Trace"}
+
+func (l Level) String() string {
+	if l < LevelError || l > LevelTrace {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
+func (l Level) title() string {
+	if l < LevelError || l > LevelTrace {
+		return "Unknown"
+	}
+	return levelTitles[l]
+}
+
+//ParseLevel returns the level with the name error, warn, info, debug or trace
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(strings.TrimSpace(name), n) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, errors.New("invalid log level " + name + ", expected error, warn, info, debug or trace")
+}
+
+//Formats of the log records
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+//Config defines how the log records are written
+type Config struct {
+	Level      Level
+	Format     string
+	Folder     string
+	Stdout     bool
+	MaxSize    int64
+	MaxBackups int
+}
+
+//DefaultConfig returns the configuration used when no log flag is defined: text records at level debug in _logs/cas-xog.log,
+//rotated when the file reaches 10MB keeping 5 old files
+func DefaultConfig() Config {
+	return Config{
+		Level:      LevelDebug,
+		Format:     FormatText,
+		Folder:     "_logs",
+		MaxSize:    10 * 1024 * 1024,
+		MaxBackups: 5,
+	}
+}
+
+//Fields are the attributes of a log record related to the processing of a driver file
+type Fields struct {
+	Driver      string
+	File        string
+	Type        string
+	Environment string
+	Duration    time.Duration
+}
+
+type record struct {
+	Time        string   `json:"time"`
+	Level       string   `json:"level"`
+	Message     string   `json:"msg"`
+	Driver      string   `json:"driver,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	Duration    *float64 `json:"duration,omitempty"`
+}
+
+var (
+	mutex  sync.Mutex
+	config = DefaultConfig()
+	output io.Writer
+)
+
+var colorReplacer = strings.NewReplacer("[red[", "\033[91m", "[green[", "\033[92m", "[yellow[", "\033[93m", "[blue[", "\033[96m", "]]", "\033[0m")
+var plainReplacer = strings.NewReplacer("[red[", "", "[green[", "", "[yellow[", "", "[blue[", "", "]]", "", "\n", "", "\r", "")
+
+//Error prints text on the screen and into log file with level error
+func Error(format string, args ...interface{}) {
+	Print(LevelError, Fields{}, format, args...)
+}
+
+//Warn prints text on the screen and into log file with level warn
+func Warn(format string, args ...interface{}) {
+	Print(LevelWarn, Fields{}, format, args...)
+}
 
 //Info prints text on the screen and into log file
 func Info(format string, args ...interface{}) {
-	format = fmt.Sprintf(format, args...)
-	clearLog("Info", format)
-	r := strings.NewReplacer("[red[", "\033[91m", "[green[", "\033[92m", "[yellow[", "\033[93m", "[blue[", "\033[96m", "]]", "\033[0m")
-	format = r.Replace(format)
-	fmt.Fprintf(colorable.NewColorableStdout(), format)
+	Print(LevelInfo, Fields{}, format, args...)
 }
 
 //Debug only insert text into the log file
 func Debug(msg string) {
-	clearLog("Debug", msg)
+	Print(LevelDebug, Fields{}, "%s", msg)
 }
 
-func clearLog(mode, msg string) {
-	r := strings.NewReplacer("[red[", "", "[green[", "", "[yellow[", "", "[blue[", "", "]]", "", "\n", "", "\r", "")
-	logger.Println(mode + ": " + r.Replace(msg))
+//Trace only insert text into the log file, used for the content of requests and responses
+func Trace(format string, args ...interface{}) {
+	Print(LevelTrace, Fields{}, format, args...)
+}
+
+//Print writes a record with the fields into the log file if the level is enabled. Levels error, warn and info, when enabled, are also printed
+//on the screen with colors, unless the records are sent to stdout
+func Print(level Level, fields Fields, format string, args ...interface{}) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if level > config.Level {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if level <= LevelInfo && !config.Stdout {
+		fmt.Fprint(colorable.NewColorableStdout(), colorReplacer.Replace(msg))
+	}
+	line := formatRecord(level, fields, plainReplacer.Replace(msg))
+	if line == "" {
+		return
+	}
+	if output != nil {
+		io.WriteString(output, line)
+	}
+	if config.Stdout {
+		io.WriteString(os.Stdout, line)
+	}
+}
+
+func formatRecord(level Level, fields Fields, msg string) string {
+	if strings.TrimSpace(msg) == "" {
+		return ""
+	}
+	now := time.Now()
+	if config.Format == FormatJSON {
+		r := record{
+			Time:        now.Format(time.RFC3339Nano),
+			Level:       level.String(),
+			Message:     strings.TrimSpace(msg),
+			Driver:      fields.Driver,
+			File:        fields.File,
+			Type:        fields.Type,
+			Environment: fields.Environment,
+		}
+		if fields.Duration > 0 {
+			seconds := fields.Duration.Seconds()
+			r.Duration = &seconds
+		}
+		data, _ := json.Marshal(r)
+		return string(data) + "\n"
+	}
+
+	line := now.Format("2006/01/02 15:04:05.000000") + " " + level.title() + ": " + msg
+	for _, f := range []struct{ name, value string }{
+		{"driver", fields.Driver},
+		{"file", fields.File},
+		{"type", fields.Type},
+		{"environment", fields.Environment},
+	} {
+		if f.value != "" {
+			line += " | " + f.name + "=" + f.value
+		}
+	}
+	if fields.Duration > 0 {
+		line += fmt.Sprintf(" | duration=%.3fs", fields.Duration.Seconds())
+	}
+	return line + "\n"
 }
 
 //InitLog initialize the io.Writer with the log file
-func InitLog() {
-	folder := "_logs"
-	util.ValidateFolder(folder)
-	file, err := os.OpenFile(folder+"/cas-xog.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+func InitLog(c Config) error {
+	if c.Format != FormatText && c.Format != FormatJSON {
+		return errors.New("invalid log format " + c.Format + ", expected text or json")
+	}
+	if c.Folder == "" {
+		c.Folder = DefaultConfig().Folder
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if closer, ok := output.(io.Closer); ok {
+		closer.Close()
+	}
+	config = c
+	output = nil
+
+	util.ValidateFolder(c.Folder)
+	file, err := newRotatingFile(strings.TrimSuffix(c.Folder, "/")+"/cas-xog.log", c.MaxSize, c.MaxBackups)
 	if err != nil {
 		fmt.Printf("\n[cas-xog]Error: Failed to open log file\n")
+		return nil
 	}
-	logger = log.New(file, "", log.Ldate|log.Ltime|log.Lmicroseconds|log.LstdFlags)
+	output = file
+	return nil
 }
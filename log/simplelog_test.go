@@ -0,0 +1,163 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func initTestLog(t *testing.T, c Config) string {
+	folder, err := ioutil.TempDir("", "cas-xog-log")
+	if err != nil {
+		t.Fatalf("Error creating log folder. Debug: %s", err.Error())
+	}
+	c.Folder = folder
+	err = InitLog(c)
+	if err != nil {
+		t.Fatalf("Error initializing log. Debug: %s", err.Error())
+	}
+	return folder
+}
+
+func resetTestLog() {
+	output.(*rotatingFile).Close()
+	output = nil
+	config = DefaultConfig()
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("Debug")
+	if err != nil || level != LevelDebug {
+		t.Errorf("Error parsing log level. Expected debug received %s", level)
+	}
+	_, err = ParseLevel("verbose")
+	if err == nil {
+		t.Errorf("Error parsing log level. Invalid level accepted")
+	}
+}
+
+func TestPrintJSONWithFields(t *testing.T) {
+	c := DefaultConfig()
+	c.Format = FormatJSON
+	c.Level = LevelDebug
+	folder := initTestLog(t, c)
+	defer os.RemoveAll(folder)
+	defer resetTestLog()
+
+	Print(LevelDebug, Fields{Driver: "xog.driver", File: "obj_project.xml", Type: "objects", Environment: "Dev", Duration: 1500 * time.Millisecond}, "\n[CAS-XOG][green[Write success]] file: %s", "obj_project.xml")
+	Trace("not enabled")
+
+	data, _ := ioutil.ReadFile(folder + "/cas-xog.log")
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Error printing log record. Expected 1 record received %d", len(lines))
+	}
+	r := record{}
+	err := json.Unmarshal([]byte(lines[0]), &r)
+	if err != nil {
+		t.Fatalf("Error printing log record. Invalid JSON. Debug: %s", err.Error())
+	}
+	if r.Level != "debug" || r.Message != "[CAS-XOG]Write success file: obj_project.xml" {
+		t.Errorf("Error printing log record. Invalid level or message: %s", lines[0])
+	}
+	if r.Driver != "xog.driver" || r.File != "obj_project.xml" || r.Type != "objects" || r.Environment != "Dev" || r.Duration == nil || *r.Duration != 1.5 {
+		t.Errorf("Error printing log record. Invalid fields: %s", lines[0])
+	}
+}
+
+type formatCounter int
+
+func (f *formatCounter) String() string {
+	*f++
+	return "formatted"
+}
+
+func TestPrintDisabledLevelNotFormatted(t *testing.T) {
+	c := DefaultConfig()
+	c.Level = LevelDebug
+	folder := initTestLog(t, c)
+	defer os.RemoveAll(folder)
+	defer resetTestLog()
+
+	var counter formatCounter
+	Trace("response: %s", &counter)
+	if counter != 0 {
+		t.Errorf("Error printing log record. Message formatted with the level trace disabled")
+	}
+	Print(LevelDebug, Fields{}, "request: %s", &counter)
+	if counter != 1 {
+		t.Errorf("Error printing log record. Expected the message formatted once received %d", counter)
+	}
+}
+
+func TestPrintDefaultConfigToWriteDebug(t *testing.T) {
+	folder := initTestLog(t, DefaultConfig())
+	defer os.RemoveAll(folder)
+	defer resetTestLog()
+
+	Debug("request detail")
+	Trace("not enabled")
+
+	data, _ := ioutil.ReadFile(folder + "/cas-xog.log")
+	if !strings.Contains(string(data), " Debug: request detail") || strings.Contains(string(data), "not enabled") {
+		t.Errorf("Error printing log record. Debug records not written by default: %s", data)
+	}
+}
+
+func TestPrintLevelErrorOnScreen(t *testing.T) {
+	c := DefaultConfig()
+	c.Level = LevelError
+	folder := initTestLog(t, c)
+	defer os.RemoveAll(folder)
+	defer resetTestLog()
+
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	Info("info message")
+	Warn("warn message")
+	Error("error message")
+	os.Stdout = stdout
+	w.Close()
+	screen, _ := ioutil.ReadAll(r)
+
+	if string(screen) != "error message" {
+		t.Errorf("Error printing log record. Expected only the error on the screen received %q", screen)
+	}
+}
+
+func TestRotateLogFile(t *testing.T) {
+	c := DefaultConfig()
+	c.Level = LevelDebug
+	c.MaxSize = 200
+	c.MaxBackups = 2
+	folder := initTestLog(t, c)
+	defer os.RemoveAll(folder)
+	defer resetTestLog()
+
+	for i := 0; i < 20; i++ {
+		Debug(strings.Repeat("x", 50))
+	}
+
+	files, _ := ioutil.ReadDir(folder)
+	if len(files) != 3 {
+		t.Fatalf("Error rotating log file. Expected 3 files received %d", len(files))
+	}
+	for _, f := range files {
+		if f.Size() > c.MaxSize {
+			t.Errorf("Error rotating log file. File %s bigger than the maximum size", f.Name())
+		}
+	}
+}
+
+func TestInitLogInvalidFormat(t *testing.T) {
+	c := DefaultConfig()
+	c.Format = "xml"
+	err := InitLog(c)
+	if err == nil {
+		t.Errorf("Error initializing log. Invalid format accepted")
+	}
+}
@@ -17,6 +17,9 @@ func main() {
 			fmt.Printf("CAS-XOG version: %s\n", version)
 			return
 		}
+		if exitCode, ok := view.Command(os.Args[1:], version); ok {
+			os.Exit(exitCode)
+		}
 	}
 
 	view.Home(version)
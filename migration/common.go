@@ -9,30 +9,58 @@ import (
 	"github.com/andreluzz/cas-xog/model"
 	"github.com/andreluzz/cas-xog/util"
 	"github.com/beevik/etree"
-	"github.com/tealeg/xlsx"
 )
 
-//ReadDataFromExcel used to create xog file from data in excel format. Only accept .xlsx extension
+//ReadDataFromExcel used to create xog file from data in excel format (.xlsx) or delimited text (.csv, .tsv)
 func ReadDataFromExcel(file *model.DriverFile) (string, error) {
+	data, err := readData(file)
+	if err != nil {
+		return constant.Undefined, err
+	}
+	if err := data.source.Err(); err != nil {
+		return constant.Undefined, errors.New("migration - " + err.Error())
+	}
+	data.xog.IndentTabs()
+	return data.xog.WriteToString()
+}
+
+//migrationData is the xog created from the data source, with the instances and the matches used to create them
+type migrationData struct {
+	xog       *etree.Document
+	template  *etree.Element
+	source    *DataSource
+	matches   []model.MatchExcel
+	instances []*etree.Element
+	rows      []int
+}
+
+//readData creates an instance of the template for each row after startRow. The index of the row used by each instance is kept in rows
+func readData(file *model.DriverFile) (*migrationData, error) {
 
 	excelStartRowIndex, xog, templateInstanceElement, err := validateReadDataFromExcelDriverAttributes(file)
 	if err != nil {
-		return constant.Undefined, err
+		return nil, err
 	}
 
 	parent := templateInstanceElement.Parent()
 	instanceCopy := templateInstanceElement.Copy()
 	templateInstanceElement.Parent().RemoveChild(templateInstanceElement)
 
-	xlFile, err := xlsx.OpenFile(util.ReplacePathSeparatorByOS(file.ExcelFile))
+	source, err := OpenDataSource(file)
 	if err != nil {
-		return constant.Undefined, errors.New("migration - error opening excel. Debug: " + err.Error())
+		return nil, errors.New("migration - " + err.Error())
+	}
+	matches, err := source.Matches(file)
+	if err != nil {
+		return nil, errors.New("migration - " + err.Error())
 	}
 
-	for index, row := range xlFile.Sheets[0].Rows {
+	data := &migrationData{xog: xog, template: instanceCopy, source: source, matches: matches}
+	for index := range source.Rows {
 		if index >= excelStartRowIndex {
 			element := instanceCopy.Copy()
-			for _, match := range file.MatchExcel {
+			values := source.Values(index, matches)
+			for i, match := range matches {
 				var e *etree.Element
 				if match.XPath == constant.Undefined {
 					e = element
@@ -41,13 +69,10 @@ func ReadDataFromExcel(file *model.DriverFile) (string, error) {
 				}
 
 				if e == nil {
-					return constant.Undefined, errors.New("migration - invalid xpath (" + match.XPath + "), element not found in template file")
+					return nil, errors.New("migration - invalid xpath (" + match.XPath + "), element not found in template file")
 				}
 
-				value := constant.Undefined
-				if match.Col-1 < len(row.Cells) {
-					value = row.Cells[match.Col-1].String()
-				}
+				value := values[i]
 
 				if match.RemoveIfNull && match.XPath != constant.Undefined && value == constant.Undefined {
 					e.Parent().RemoveChild(e)
@@ -64,23 +89,16 @@ func ReadDataFromExcel(file *model.DriverFile) (string, error) {
 				}
 			}
 			parent.AddChild(element)
+			data.instances = append(data.instances, element)
+			data.rows = append(data.rows, index)
 		}
 	}
-	xog.IndentTabs()
-	return xog.WriteToString()
+	return data, nil
 }
 
 func fillMultiValued(match model.MatchExcel, value string, e *etree.Element) {
-	separator := ";"
-	if match.Separator != constant.Undefined {
-		separator = match.Separator
-	}
-	elemName := "Value"
-	if match.Element != constant.Undefined {
-		elemName = match.Element
-	}
-	for _, val := range strings.Split(value, separator) {
-		v := e.CreateElement(elemName)
+	for _, val := range strings.Split(value, separatorOf(match)) {
+		v := e.CreateElement(elementOf(match))
 		if match.Attr != constant.Undefined {
 			v.CreateAttr(match.Attr, strings.TrimSpace(val))
 		} else {
@@ -92,6 +110,22 @@ func fillMultiValued(match model.MatchExcel, value string, e *etree.Element) {
 	}
 }
 
+//separatorOf returns the separator of the multi-valued match, ';' by default
+func separatorOf(match model.MatchExcel) string {
+	if match.Separator != constant.Undefined {
+		return match.Separator
+	}
+	return ";"
+}
+
+//elementOf returns the tag of the elements created for each value of the multi-valued match, Value by default
+func elementOf(match model.MatchExcel) string {
+	if match.Element != constant.Undefined {
+		return match.Element
+	}
+	return "Value"
+}
+
 func validateReadDataFromExcelDriverAttributes(file *model.DriverFile) (int, *etree.Document, *etree.Element, error) {
 
 	xog := etree.NewDocument()
@@ -120,61 +154,3 @@ func validateReadDataFromExcelDriverAttributes(file *model.DriverFile) (int, *et
 
 	return excelStartRowIndex, xog, templateInstanceElement, nil
 }
-
-//ExportInstancesToExcel used to create excel file with the data from xog file
-func ExportInstancesToExcel(xog *etree.Document, file *model.DriverFile, folder string) error {
-	util.ValidateFolder(folder)
-	xlsxFile := xlsx.NewFile()
-	sheet, _ := xlsxFile.AddSheet("Instances")
-
-	for _, i := range xog.FindElements("//" + file.InstanceTag) {
-		instance := etree.NewDocument()
-		instance.AddChild(i)
-
-		row := sheet.AddRow()
-
-		for _, match := range file.MatchExcel {
-			var e *etree.Element
-
-			if match.XPath == constant.Undefined {
-				e = instance.ChildElements()[0]
-			} else {
-				e = instance.FindElement(match.XPath)
-			}
-
-			cell := row.AddCell()
-
-			if e == nil {
-				cell.Value = ""
-				continue
-			}
-
-			value := ""
-			if match.AttributeName != "" {
-				value = e.SelectAttrValue(match.AttributeName, "")
-			} else {
-				if match.MultiValued {
-					separator := ";"
-					if match.Separator != "" {
-						separator = match.Separator
-					}
-					for _, v := range e.FindElements("//Value") {
-						value += v.Text() + separator
-					}
-					value = value[:len(value)-1]
-				} else {
-					value = e.Text()
-				}
-			}
-			cell.Value = value
-		}
-	}
-
-	util.ValidateFolder(folder + util.GetPathFolder(util.ReplacePathSeparatorByOS(file.ExcelFile)))
-	err := xlsxFile.Save(folder + util.ReplacePathSeparatorByOS(file.ExcelFile))
-	if err != nil {
-		return errors.New("migration - ExportInstancesToExcel saving excel error. Debug: " + err.Error())
-	}
-
-	return nil
-}
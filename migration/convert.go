@@ -0,0 +1,285 @@
+package migration
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+)
+
+//DefaultDateOutput is the format of the converted dates when the match does not define the attribute dateOutput
+const DefaultDateOutput = "yyyy-MM-ddTHH:mm:ss"
+
+//maxReportedErrors limits the conversion errors described in the error returned by Err
+const maxReportedErrors = 20
+
+//excelEpoch is the day 0 of the excel serial dates, considering the 1900 leap year bug
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+//dateTokens translates the tokens of the date formats to the layout used by time.Parse, longest tokens first
+var dateTokens = []struct{ token, layout string }{
+	{"yyyy", "2006"}, {"yy", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"dd", "02"}, {"d", "2"},
+	{"HH", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"ss", "05"}, {"a", "PM"},
+}
+
+//RowError describes an invalid value of the data source
+type RowError struct {
+	Sheet     string
+	Row       int
+	Column    string
+	Attribute string
+	Value     string
+	Message   string
+}
+
+func (e RowError) Error() string {
+	if e.Column == constant.Undefined {
+		return fmt.Sprintf("sheet %s, row %d: %s", e.Sheet, e.Row, e.Message)
+	}
+	return fmt.Sprintf("sheet %s, row %d, column %s: %s", e.Sheet, e.Row, e.Column, e.Message)
+}
+
+//Values returns the value of each match in the row, converted as defined by the attributes of the match. The index of the row starts at 0.
+//Values that cannot be converted are returned empty and the errors, with sheet, row and column, are returned by Err
+func (s *DataSource) Values(index int, matches []model.MatchExcel) []string {
+	row := s.Rows[index]
+	values := make([]string, len(matches))
+	for i, match := range matches {
+		value, err := s.convertMatch(match, Value(row, match.Col))
+		if err != nil {
+			s.errors = append(s.errors, RowError{Sheet: s.Sheet, Row: index + 1, Column: columnLabel(match), Value: Value(row, match.Col), Message: err.Error()})
+			continue
+		}
+		values[i] = value
+	}
+	return values
+}
+
+//Err returns an error describing the values that could not be converted by Values
+func (s *DataSource) Err() error {
+	if len(s.errors) == 0 {
+		return nil
+	}
+	lines := []string{}
+	for i, e := range s.errors {
+		if i == maxReportedErrors {
+			lines = append(lines, fmt.Sprintf("and %d more", len(s.errors)-maxReportedErrors))
+			break
+		}
+		lines = append(lines, e.Error())
+	}
+	return fmt.Errorf("%d values could not be converted:\n%s", len(s.errors), strings.Join(lines, "\n"))
+}
+
+//columnLabel returns the number of the column followed by the header name, if defined
+func columnLabel(match model.MatchExcel) string {
+	column := strconv.Itoa(match.Col)
+	if match.Header != constant.Undefined {
+		column += " (" + match.Header + ")"
+	}
+	return column
+}
+
+//validateConverters checks the attributes of the match converters and compiles the regex
+func (s *DataSource) validateConverters(match model.MatchExcel) error {
+	if match.Regex != constant.Undefined {
+		r, err := regexp.Compile(match.Regex)
+		if err != nil {
+			return fmt.Errorf("match with invalid regex (%s). Debug: %s", match.Regex, err.Error())
+		}
+		if s.regexps == nil {
+			s.regexps = map[string]*regexp.Regexp{}
+		}
+		s.regexps[match.Regex] = r
+	}
+	switch strings.ToLower(match.Case) {
+	case constant.Undefined, "upper", "lower":
+	default:
+		return fmt.Errorf("match with invalid case (%s), expected upper or lower", match.Case)
+	}
+	switch match.DecimalSeparator {
+	case constant.Undefined, ",", ".":
+	default:
+		return fmt.Errorf("match with invalid decimalSeparator (%s), expected ',' or '.'", match.DecimalSeparator)
+	}
+	if match.BooleanOutput != constant.Undefined && len(strings.Split(match.BooleanOutput, ";")) != 2 {
+		return fmt.Errorf("match with invalid booleanOutput (%s), expected the true and false values separated by ';'", match.BooleanOutput)
+	}
+	return nil
+}
+
+//convertMatch converts each value of a multi-valued match or the whole value
+func (s *DataSource) convertMatch(match model.MatchExcel, value string) (string, error) {
+	if !match.MultiValued || value == constant.Undefined {
+		return s.convert(match, value)
+	}
+	separator := separatorOf(match)
+	converted := []string{}
+	for _, v := range strings.Split(value, separator) {
+		c, err := s.convert(match, strings.TrimSpace(v))
+		if err != nil {
+			return constant.Undefined, err
+		}
+		if c != constant.Undefined {
+			converted = append(converted, c)
+		}
+	}
+	return strings.Join(converted, separator), nil
+}
+
+//convert applies, in order, trim, regex, map, boolean, number, date and case. The default is used when the result is empty
+func (s *DataSource) convert(match model.MatchExcel, value string) (string, error) {
+	var err error
+	if match.Trim {
+		value = strings.TrimSpace(value)
+	}
+	if match.Regex != constant.Undefined {
+		value = extract(s.regexps[match.Regex], value)
+	}
+	if value != constant.Undefined {
+		if len(match.Maps) > 0 {
+			value, err = mapValue(match.Maps, value)
+			if err != nil {
+				return constant.Undefined, err
+			}
+		}
+		if match.TrueValues != constant.Undefined || match.FalseValues != constant.Undefined {
+			value, err = convertBoolean(match, value)
+			if err != nil {
+				return constant.Undefined, err
+			}
+		}
+		if match.DecimalSeparator != constant.Undefined {
+			value, err = convertNumber(value, match.DecimalSeparator)
+			if err != nil {
+				return constant.Undefined, err
+			}
+		}
+		if match.DateFormat != constant.Undefined || match.DateOutput != constant.Undefined {
+			value, err = convertDate(value, match.DateFormat, match.DateOutput)
+			if err != nil {
+				return constant.Undefined, err
+			}
+		}
+		switch strings.ToLower(match.Case) {
+		case "upper":
+			value = strings.ToUpper(value)
+		case "lower":
+			value = strings.ToLower(value)
+		}
+	}
+	if value == constant.Undefined {
+		value = match.Default
+	}
+	return value, nil
+}
+
+//extract returns the first group of the regex found in the value, or the whole match if the regex has no groups
+func extract(r *regexp.Regexp, value string) string {
+	found := r.FindStringSubmatch(value)
+	if found == nil {
+		return constant.Undefined
+	}
+	if len(found) > 1 {
+		return found[1]
+	}
+	return found[0]
+}
+
+//mapValue returns the code mapped to the name, ignoring case. Values that already are one of the codes are kept
+func mapValue(maps []model.MatchMap, value string) (string, error) {
+	for _, m := range maps {
+		if strings.EqualFold(strings.TrimSpace(m.From), strings.TrimSpace(value)) {
+			return m.To, nil
+		}
+	}
+	for _, m := range maps {
+		if m.To == value {
+			return value, nil
+		}
+	}
+	return constant.Undefined, fmt.Errorf("value '%s' not found in the map", value)
+}
+
+func convertBoolean(match model.MatchExcel, value string) (string, error) {
+	output := []string{"true", "false"}
+	if match.BooleanOutput != constant.Undefined {
+		output = strings.Split(match.BooleanOutput, ";")
+	}
+	for i, values := range []string{match.TrueValues, match.FalseValues} {
+		for _, v := range strings.Split(values, ";") {
+			if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+				return output[i], nil
+			}
+		}
+	}
+	return constant.Undefined, fmt.Errorf("value '%s' not found in trueValues (%s) or falseValues (%s)", value, match.TrueValues, match.FalseValues)
+}
+
+//convertNumber removes the thousands separators and returns the number with '.' as decimal separator
+func convertNumber(value, decimalSeparator string) (string, error) {
+	thousands := "."
+	if decimalSeparator == "." {
+		thousands = ","
+	}
+	number := strings.NewReplacer(thousands, "", " ", "", "\u00a0", "", "'", "").Replace(strings.TrimSpace(value))
+	number = strings.Replace(number, decimalSeparator, ".", 1)
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return constant.Undefined, fmt.Errorf("invalid number '%s' for decimal separator '%s'", value, decimalSeparator)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+//convertDate parses the value with the format and writes it with the output format. Numbers are read as excel serial dates
+func convertDate(value, format, output string) (string, error) {
+	if output == constant.Undefined {
+		output = DefaultDateOutput
+	}
+	value = strings.TrimSpace(value)
+	layout := time.RFC3339
+	if format != constant.Undefined {
+		layout = dateLayout(format)
+	}
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		serial, serialErr := strconv.ParseFloat(value, 64)
+		if serialErr != nil || serial < 0 {
+			if format == constant.Undefined {
+				return constant.Undefined, fmt.Errorf("invalid date '%s', define the attribute dateFormat", value)
+			}
+			return constant.Undefined, fmt.Errorf("invalid date '%s' for format %s", value, format)
+		}
+		date = excelEpoch.Add(time.Duration(math.Round(serial*86400)) * time.Second)
+	}
+	return date.Format(dateLayout(output)), nil
+}
+
+//dateLayout translates a format like dd/MM/yyyy HH:mm to the layout of the time package. Other characters are kept
+func dateLayout(format string) string {
+	layout := strings.Builder{}
+	for i := 0; i < len(format); {
+		found := false
+		for _, t := range dateTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				layout.WriteString(t.layout)
+				i += len(t.token)
+				found = true
+				break
+			}
+		}
+		if !found {
+			layout.WriteByte(format[i])
+			i++
+		}
+	}
+	return layout.String()
+}
@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/model"
+)
+
+func TestDataSourceValuesToConvertMatches(t *testing.T) {
+	source := &DataSource{
+		Sheet: "Projects",
+		Rows: [][]string{
+			{"Code", "Start", "Budget", "Active", "Status", "Manager", "Area", "Finish"},
+			{"  prj001 ", "31/01/2020", "1.234,50", "Sim", "Em andamento;Concluído", "João Silva (js01)", "", "43861.5"},
+		},
+	}
+	matches := []model.MatchExcel{
+		{Col: 1, Trim: true, Case: "upper"},
+		{Col: 2, DateFormat: "dd/MM/yyyy"},
+		{Col: 3, DecimalSeparator: ","},
+		{Col: 4, TrueValues: "Sim;S", FalseValues: "Não;N", BooleanOutput: "1;0"},
+		{Col: 5, MultiValued: true, Maps: []model.MatchMap{{From: "em andamento", To: "CAL_IN_PROGRESS"}, {From: "Concluído", To: "CAL_DONE"}}},
+		{Col: 6, Regex: `\((\w+)\)`},
+		{Col: 7, Default: "AREA_DEFAULT"},
+		{Col: 8, DateOutput: "yyyy-MM-dd HH:mm"},
+	}
+
+	resolved, err := source.Matches(&model.DriverFile{MatchExcel: matches})
+	if err != nil {
+		t.Fatalf("Error converting values. Debug: %s", err.Error())
+	}
+	values := source.Values(1, resolved)
+	if err := source.Err(); err != nil {
+		t.Fatalf("Error converting values. Debug: %s", err.Error())
+	}
+
+	expected := []string{"PRJ001", "2020-01-31T00:00:00", "1234.5", "1", "CAL_IN_PROGRESS;CAL_DONE", "js01", "AREA_DEFAULT", "2020-01-31 12:00"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Error converting values. Expected %q and received %q", expected, values)
+	}
+}
+
+func TestDataSourceValuesToReturnConversionErrors(t *testing.T) {
+	source := &DataSource{
+		Sheet: "Projects",
+		Rows: [][]string{
+			{"Start", "Budget", "Active", "Status"},
+			{"2020-02-31", "12a", "Maybe", "Unknown"},
+		},
+	}
+	file := &model.DriverFile{
+		HeaderRow: "1",
+		MatchExcel: []model.MatchExcel{
+			{Header: "Start", DateFormat: "yyyy-MM-dd"},
+			{Col: 2, DecimalSeparator: "."},
+			{Col: 3, TrueValues: "Yes", FalseValues: "No"},
+			{Col: 4, Maps: []model.MatchMap{{From: "Done", To: "CAL_DONE"}}},
+		},
+	}
+
+	matches, err := source.Matches(file)
+	if err != nil {
+		t.Fatalf("Error converting values. Debug: %s", err.Error())
+	}
+	values := source.Values(1, matches)
+	if !reflect.DeepEqual(values, []string{"", "", "", ""}) {
+		t.Errorf("Error converting values. Expected empty values and received %q", values)
+	}
+
+	err = source.Err()
+	if err == nil {
+		t.Fatalf("Error converting values. Debug: not returning conversion errors")
+	}
+	for _, e := range []string{
+		"4 values could not be converted",
+		"sheet Projects, row 2, column 1 (Start): invalid date '2020-02-31' for format yyyy-MM-dd",
+		"sheet Projects, row 2, column 2: invalid number '12a' for decimal separator '.'",
+		"sheet Projects, row 2, column 3: value 'Maybe' not found in trueValues (Yes) or falseValues (No)",
+		"sheet Projects, row 2, column 4: value 'Unknown' not found in the map",
+	} {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("Error converting values. Expected %q in error %s", e, err.Error())
+		}
+	}
+}
+
+func TestDataSourceMatchesToReturnErrorInvalidConverters(t *testing.T) {
+	source := &DataSource{Sheet: "Projects", Rows: [][]string{{"a"}}}
+	for _, match := range []model.MatchExcel{
+		{Col: 1, Regex: "(["},
+		{Col: 1, Case: "title"},
+		{Col: 1, DecimalSeparator: ";"},
+		{Col: 1, TrueValues: "Yes", BooleanOutput: "1"},
+	} {
+		_, err := source.Matches(&model.DriverFile{MatchExcel: []model.MatchExcel{match}})
+		if err == nil {
+			t.Errorf("Error validating converters. Debug: accepted invalid match %+v", match)
+		}
+	}
+}
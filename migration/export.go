@@ -0,0 +1,308 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/beevik/etree"
+	"github.com/tealeg/xlsx"
+)
+
+//DefaultExportSheet is the name of the sheet with the instances when the attribute sheet is not defined
+const DefaultExportSheet = "Instances"
+
+var predicateValueRegexp = regexp.MustCompile(`\[@[\w:.-]+=['"]([^'"]+)['"]\]`)
+
+//exportSheet has the header and the values of each row of an exported sheet
+type exportSheet struct {
+	name    string
+	headers []string
+	rows    [][]string
+}
+
+//ExportInstancesToExcel used to create excel file with the data from xog file. The header row, when defined, has the names used by the
+//match tags with attribute header, so the excel can be changed and imported again with the migrate action. Each tag sheet adds a sheet
+//with the sub elements or the custom attributes of the instances, identified by the value of the first match in the first column.
+//OBS and department instances are exported as their units tree, in the layout used to import them
+func ExportInstancesToExcel(xog *etree.Document, file *model.DriverFile, folder string) error {
+	switch file.Type {
+	case constant.TypeOBSInstance:
+		return exportHierarchyToExcel(xog, file, folder, obsHierarchy)
+	case constant.TypeDepartmentInstance:
+		return exportHierarchyToExcel(xog, file, folder, departmentHierarchy)
+	}
+
+	util.ValidateFolder(folder)
+
+	headerRow, err := exportHeaderRow(file)
+	if err != nil {
+		return errors.New("migration - " + err.Error())
+	}
+	if len(file.Sheets) > 0 && len(file.MatchExcel) == 0 {
+		return errors.New("migration - tag sheet requires a match to identify the instance in the first column")
+	}
+
+	instances := []*etree.Element{}
+	for _, i := range xog.FindElements("//" + file.InstanceTag) {
+		instances = append(instances, detach(i))
+	}
+
+	instancesSheet := exportSheet{name: DefaultExportSheet, headers: uniqueHeaders(exportHeaders(file.MatchExcel))}
+	if file.Sheet != constant.Undefined {
+		instancesSheet.name = file.Sheet
+	}
+	keys := make([]string, len(instances))
+	for n, instance := range instances {
+		values := exportValues(instance, file.MatchExcel)
+		if len(values) > 0 {
+			keys[n] = values[0]
+		}
+		instancesSheet.rows = append(instancesSheet.rows, values)
+	}
+
+	sheets := []exportSheet{instancesSheet}
+	for _, s := range file.Sheets {
+		if err := ValidateExcelSheet(s); err != nil {
+			return errors.New("migration - " + err.Error())
+		}
+		if s.CustomAttributes {
+			sheets = append(sheets, customAttributesSheet(s.Name, instancesSheet.headers[0], instances, keys))
+		} else {
+			sheets = append(sheets, subElementsSheet(s, instancesSheet.headers[0], instances, keys))
+		}
+	}
+
+	xlsxFile := xlsx.NewFile()
+	for _, s := range sheets {
+		err := addExportSheet(xlsxFile, s, headerRow)
+		if err != nil {
+			return errors.New("migration - ExportInstancesToExcel " + err.Error())
+		}
+	}
+
+	util.ValidateFolder(folder + util.GetPathFolder(util.ReplacePathSeparatorByOS(file.ExcelFile)))
+	err = xlsxFile.Save(folder + util.ReplacePathSeparatorByOS(file.ExcelFile))
+	if err != nil {
+		return errors.New("migration - ExportInstancesToExcel saving excel error. Debug: " + err.Error())
+	}
+
+	return nil
+}
+
+//ValidateExcelSheet checks if the extra sheet defines a name and either the xpath of the sub elements or customAttributes
+func ValidateExcelSheet(s model.ExcelSheet) error {
+	if s.Name == constant.Undefined {
+		return errors.New("tag sheet requires the attribute name")
+	}
+	if s.XPath != constant.Undefined && s.CustomAttributes {
+		return fmt.Errorf("tag sheet %s cannot define xpath and customAttributes together", s.Name)
+	}
+	if s.XPath == constant.Undefined && !s.CustomAttributes {
+		return fmt.Errorf("tag sheet %s requires the attribute xpath or customAttributes", s.Name)
+	}
+	return nil
+}
+
+//exportHeaderRow returns the line of the header, 0 if no header should be written. Without the attribute headerRow the header
+//is written in the first line when a match defines the attribute header or extra sheets are defined
+func exportHeaderRow(file *model.DriverFile) (int, error) {
+	if file.HeaderRow != constant.Undefined {
+		row, err := strconv.Atoi(file.HeaderRow)
+		if err != nil || row < 1 {
+			return 0, fmt.Errorf("invalid headerRow (%s), expected a number greater than 0", file.HeaderRow)
+		}
+		return row, nil
+	}
+	if len(file.Sheets) > 0 {
+		return 1, nil
+	}
+	for _, match := range file.MatchExcel {
+		if match.Header != constant.Undefined {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+//detach copies the element to a new document, so the xpaths starting with // are searched only inside it
+func detach(e *etree.Element) *etree.Element {
+	doc := etree.NewDocument()
+	c := e.Copy()
+	doc.AddChild(c)
+	return c
+}
+
+//exportHeaders returns the attribute header of each match or a name created from the xpath and attribute
+func exportHeaders(matches []model.MatchExcel) []string {
+	headers := []string{}
+	for _, match := range matches {
+		headers = append(headers, headerName(match))
+	}
+	return headers
+}
+
+//headerName uses the value of the last xpath predicate, like the name of a ColumnValue, or the last tag of the xpath followed by the attribute
+func headerName(match model.MatchExcel) string {
+	if match.Header != constant.Undefined {
+		return match.Header
+	}
+	name := lastStep(match.XPath)
+	if found := predicateValueRegexp.FindStringSubmatch(name); found != nil {
+		name = found[1]
+	} else if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	switch {
+	case match.AttributeName == constant.Undefined:
+		return name
+	case name == constant.Undefined:
+		return match.AttributeName
+	default:
+		return name + "." + match.AttributeName
+	}
+}
+
+//lastStep returns the part of the xpath after the last '/' that is not inside a predicate
+func lastStep(xpath string) string {
+	depth := 0
+	start := 0
+	for i, c := range xpath {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				start = i + 1
+			}
+		}
+	}
+	return xpath[start:]
+}
+
+//uniqueHeaders adds a number to the repeated headers, keeping each column found by its header on import
+func uniqueHeaders(headers []string) []string {
+	unique := make([]string, len(headers))
+	used := map[string]bool{}
+	for i, h := range headers {
+		name := h
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s (%d)", h, n)
+		}
+		used[strings.ToLower(name)] = true
+		unique[i] = name
+	}
+	return unique
+}
+
+//exportValues returns the value of each match in the element, written as the migrate action reads it
+func exportValues(element *etree.Element, matches []model.MatchExcel) []string {
+	values := []string{}
+	for _, match := range matches {
+		e := element
+		if match.XPath != constant.Undefined {
+			e = element.FindElement(match.XPath)
+		}
+		value := constant.Undefined
+		if e != nil {
+			switch {
+			case match.AttributeName != constant.Undefined:
+				value = e.SelectAttrValue(match.AttributeName, constant.Undefined)
+			case match.MultiValued:
+				value = multiValuedText(match, e)
+			default:
+				value = e.Text()
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+//multiValuedText joins the values of the elements created by the migrate action for a multi-valued match
+func multiValuedText(match model.MatchExcel, e *etree.Element) string {
+	values := []string{}
+	for _, v := range e.SelectElements(elementOf(match)) {
+		if match.Attr != constant.Undefined {
+			values = append(values, v.SelectAttrValue(match.Attr, constant.Undefined))
+		} else {
+			values = append(values, v.Text())
+		}
+	}
+	return strings.Join(values, separatorOf(match))
+}
+
+//subElementsSheet has a row for each element found by the xpath inside the instances, with the key of the instance in the first column
+func subElementsSheet(s model.ExcelSheet, keyHeader string, instances []*etree.Element, keys []string) exportSheet {
+	sheet := exportSheet{name: s.Name, headers: uniqueHeaders(append([]string{keyHeader}, exportHeaders(s.MatchExcel)...))}
+	for n, instance := range instances {
+		for _, e := range instance.FindElements(s.XPath) {
+			sheet.rows = append(sheet.rows, append([]string{keys[n]}, exportValues(detach(e), s.MatchExcel)...))
+		}
+	}
+	return sheet
+}
+
+//customAttributesSheet has a column for each ColumnValue found in the instances, named by the attribute code. Multi-valued
+//attributes have the values separated by ';'
+func customAttributesSheet(name, keyHeader string, instances []*etree.Element, keys []string) exportSheet {
+	columns := map[string]int{}
+	codes := []string{}
+	values := make([]map[string]string, len(instances))
+	for n, instance := range instances {
+		values[n] = map[string]string{}
+		for _, c := range instance.FindElements("//ColumnValue") {
+			code := c.SelectAttrValue("name", constant.Undefined)
+			if _, ok := columns[code]; !ok {
+				columns[code] = len(codes)
+				codes = append(codes, code)
+			}
+			if len(c.ChildElements()) > 0 || strings.TrimSpace(c.Text()) == constant.Undefined {
+				values[n][code] = multiValuedText(model.MatchExcel{}, c)
+			} else {
+				values[n][code] = c.Text()
+			}
+		}
+	}
+
+	sheet := exportSheet{name: name, headers: uniqueHeaders(append([]string{keyHeader}, codes...))}
+	for n := range instances {
+		row := []string{keys[n]}
+		for _, code := range codes {
+			row = append(row, values[n][code])
+		}
+		sheet.rows = append(sheet.rows, row)
+	}
+	return sheet
+}
+
+func addExportSheet(xlsxFile *xlsx.File, s exportSheet, headerRow int) error {
+	sheet, err := xlsxFile.AddSheet(s.name)
+	if err != nil {
+		return fmt.Errorf("invalid sheet %s. Debug: %s", s.name, err.Error())
+	}
+	if headerRow > 0 {
+		for i := 1; i < headerRow; i++ {
+			sheet.AddRow()
+		}
+		addExportRow(sheet, s.headers)
+	}
+	for _, values := range s.rows {
+		addExportRow(sheet, values)
+	}
+	return nil
+}
+
+func addExportRow(sheet *xlsx.Sheet, values []string) {
+	row := sheet.AddRow()
+	for _, v := range values {
+		row.AddCell().Value = v
+	}
+}
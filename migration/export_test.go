@@ -0,0 +1,148 @@
+package migration
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/beevik/etree"
+	"github.com/tealeg/xlsx"
+)
+
+func exportMatchesMock() []model.MatchExcel {
+	return []model.MatchExcel{
+		{AttributeName: "instanceCode"},
+		{XPath: "//ColumnValue[@name='code']"},
+		{XPath: "//ColumnValue[@name='name']"},
+		{XPath: "//ColumnValue[@name='status_novo']"},
+		{XPath: "//ColumnValue[@name='multivalue_status']", MultiValued: true},
+		{XPath: "//ColumnValue[@name='analista']"},
+	}
+}
+
+func TestExportInstancesToExcelToImportAgain(t *testing.T) {
+	folder := packageMockFolder + "_export/"
+	defer os.RemoveAll(folder)
+
+	file := model.DriverFile{
+		Type:          constant.TypeCustomObjectInstance,
+		ExportToExcel: true,
+		ExcelFile:     "instances.xlsx",
+		InstanceTag:   "instance",
+		HeaderRow:     "1",
+		MatchExcel:    exportMatchesMock(),
+	}
+	xog := etree.NewDocument()
+	xog.ReadFromFile(packageMockFolder + "result.xml")
+	err := ExportInstancesToExcel(xog, &file, folder)
+	if err != nil {
+		t.Fatalf("Error exporting instances to excel file. Debug: %s", err.Error())
+	}
+	if len(xog.FindElements("//instance")) != 3 {
+		t.Errorf("Error exporting instances to excel file. Debug: instances removed from the xog")
+	}
+
+	migrate := model.DriverFile{
+		Template:      packageMockFolder + "template.xml",
+		ExcelFile:     folder + file.ExcelFile,
+		Sheet:         DefaultExportSheet,
+		InstanceTag:   "instance",
+		ExcelStartRow: "2",
+		MatchExcel: []model.MatchExcel{
+			{Header: "instanceCode", AttributeName: "instanceCode"},
+			{Header: "code", XPath: "//ColumnValue[@name='code']"},
+			{Header: "name", XPath: "//ColumnValue[@name='name']"},
+			{Header: "status_novo", XPath: "//ColumnValue[@name='status_novo']", RemoveIfNull: true},
+			{Header: "multivalue_status", XPath: "//ColumnValue[@name='multivalue_status']", MultiValued: true},
+			{Header: "analista", XPath: "//ColumnValue[@name='analista']"},
+		},
+	}
+	result, err := ReadDataFromExcel(&migrate)
+	if err != nil {
+		t.Fatalf("Error importing the exported excel file. Debug: %s", err.Error())
+	}
+	xog.IndentTabs()
+	expected, _ := xog.WriteToString()
+	if result != expected {
+		t.Errorf("Error importing the exported excel file. Debug: result different from the exported xog")
+	}
+}
+
+func TestExportInstancesToExcelToReturnExtraSheets(t *testing.T) {
+	folder := packageMockFolder + "_export/"
+	defer os.RemoveAll(folder)
+
+	xog := etree.NewDocument()
+	xog.ReadFromFile(packageMockFolder + "result.xml")
+	multiValue := xog.FindElement("//instance[@instanceCode='S0000003']//ColumnValue[@name='multivalue_status']")
+	multiValue.RemoveChild(multiValue.SelectElement("Value"))
+
+	file := model.DriverFile{
+		ExportToExcel: true,
+		ExcelFile:     "sheets.xlsx",
+		InstanceTag:   "instance",
+		Sheet:         "Systems",
+		MatchExcel:    exportMatchesMock()[:1],
+		Sheets: []model.ExcelSheet{
+			{Name: "Status", XPath: "//ColumnValue[@name='multivalue_status']/Value", MatchExcel: []model.MatchExcel{{Header: "Status"}}},
+			{Name: "Attributes", CustomAttributes: true},
+		},
+	}
+	err := ExportInstancesToExcel(xog, &file, folder)
+	if err != nil {
+		t.Fatalf("Error exporting instances to excel file. Debug: %s", err.Error())
+	}
+
+	xlsxFile, err := xlsx.OpenFile(folder + file.ExcelFile)
+	if err != nil {
+		t.Fatalf("Error exporting instances to excel file. Debug: %s", err.Error())
+	}
+	sheets, _ := xlsxFile.ToSlice()
+	expected := [][][]string{
+		{{"instanceCode"}, {"S0000001"}, {"S0000002"}, {"S0000003"}},
+		{{"instanceCode", "Status"}, {"S0000001", "CAL_CLOSED"}, {"S0000001", "CAL_DONE"}, {"S0000001", "CAL_IN_PROGRESS"}, {"S0000002", "CAL_CLOSED"}, {"S0000002", "CAL_DONE"}},
+		{
+			{"instanceCode", "code", "name", "status_novo", "analista", "multivalue_status"},
+			{"S0000001", "S0000001", "Nome sistema", "CAL_DONE", "Username", "CAL_CLOSED;CAL_DONE;CAL_IN_PROGRESS"},
+			{"S0000002", "S0000002", "Nome sistema", "", "Username", "CAL_CLOSED;CAL_DONE"},
+			{"S0000003", "S0000003", "Nome sistema", "CAL_DONE", "Username", ""},
+		},
+	}
+	if !reflect.DeepEqual(sheets, expected) {
+		t.Errorf("Error exporting instances to excel file. Expected %q and received %q", expected, sheets)
+	}
+	if xlsxFile.Sheets[0].Name != "Systems" {
+		t.Errorf("Error exporting instances to excel file. Debug: invalid sheet name %s", xlsxFile.Sheets[0].Name)
+	}
+
+	file.Sheets = []model.ExcelSheet{{Name: "Attributes", XPath: "//ColumnValue", CustomAttributes: true}}
+	err = ExportInstancesToExcel(xog, &file, folder)
+	if err == nil {
+		t.Errorf("Error exporting instances to excel file. Debug: not validating sheet with xpath and customAttributes")
+	}
+}
+
+func TestHeaderName(t *testing.T) {
+	tests := []struct {
+		match    model.MatchExcel
+		expected string
+	}{
+		{model.MatchExcel{AttributeName: "resourceId"}, "resourceId"},
+		{model.MatchExcel{XPath: "//ColumnValue[@name='partition_code']"}, "partition_code"},
+		{model.MatchExcel{XPath: "//PersonalInformation", AttributeName: "displayName"}, "PersonalInformation.displayName"},
+		{model.MatchExcel{XPath: "//OBSAssoc[@id='corpLocationOBS']", AttributeName: "unitPath"}, "corpLocationOBS.unitPath"},
+		{model.MatchExcel{XPath: "//Tasks/Task[position()=1]"}, "Task"},
+		{model.MatchExcel{XPath: "//Task", Header: "Tasks"}, "Tasks"},
+	}
+
+	for _, test := range tests {
+		if name := headerName(test.match); name != test.expected {
+			t.Errorf("Error creating header name. Expected %s and received %s", test.expected, name)
+		}
+	}
+	if h := uniqueHeaders([]string{"code", "Code", "code"}); !reflect.DeepEqual(h, []string{"code", "Code (2)", "code (3)"}) {
+		t.Errorf("Error creating unique headers. Received %q", h)
+	}
+}
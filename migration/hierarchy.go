@@ -0,0 +1,104 @@
+package migration
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/beevik/etree"
+	"github.com/tealeg/xlsx"
+)
+
+//hierarchy defines the tags of the units tree and how to read the code and name of each unit
+type hierarchy struct {
+	root string
+	unit string
+	code func(e *etree.Element) string
+	name func(e *etree.Element) string
+}
+
+var obsHierarchy = hierarchy{
+	root: "//obs",
+	unit: "unit",
+	code: func(e *etree.Element) string { return e.SelectAttrValue("code", constant.Undefined) },
+	name: func(e *etree.Element) string { return e.SelectAttrValue("name", constant.Undefined) },
+}
+
+var departmentHierarchy = hierarchy{
+	root: "//Departments",
+	unit: "Department",
+	code: func(e *etree.Element) string { return e.SelectAttrValue("department_code", constant.Undefined) },
+	name: func(e *etree.Element) string {
+		if d := e.SelectElement("Description"); d != nil && d.Text() != constant.Undefined {
+			return d.Text()
+		}
+		return e.SelectAttrValue("short_description", constant.Undefined)
+	},
+}
+
+//exportHierarchyToExcel writes a line for each unit with the code and name of all its parents followed by its own, the layout
+//read by the obsInstance and departmentInstance tags with the attribute excel. The header is written in the line defined by headerRow, 1 by default
+func exportHierarchyToExcel(xog *etree.Document, file *model.DriverFile, folder string, h hierarchy) error {
+	util.ValidateFolder(folder)
+
+	headerRow := 1
+	if file.HeaderRow != constant.Undefined {
+		row, err := exportHeaderRow(file)
+		if err != nil {
+			return errors.New("migration - " + err.Error())
+		}
+		headerRow = row
+	}
+
+	root := xog.FindElement(h.root)
+	if root == nil {
+		return errors.New("migration - no element " + h.root[2:] + " found to export to excel")
+	}
+	rows := hierarchyRows(root, h, []string{})
+
+	depth := 0
+	for _, r := range rows {
+		if len(r)/2 > depth {
+			depth = len(r) / 2
+		}
+	}
+	headers := []string{}
+	for level := 1; level <= depth; level++ {
+		headers = append(headers, "Level"+strconv.Itoa(level)+" Code", "Level"+strconv.Itoa(level)+" Name")
+	}
+
+	//the line of a unit ends with an empty cell, read by the import as the end of the unit path
+	sheet := exportSheet{name: DefaultExportSheet, headers: headers}
+	if file.Sheet != constant.Undefined {
+		sheet.name = file.Sheet
+	}
+	for _, r := range rows {
+		sheet.rows = append(sheet.rows, append(r, make([]string, depth*2+1-len(r))...))
+	}
+
+	xlsxFile := xlsx.NewFile()
+	err := addExportSheet(xlsxFile, sheet, headerRow)
+	if err != nil {
+		return errors.New("migration - ExportInstancesToExcel " + err.Error())
+	}
+
+	util.ValidateFolder(folder + util.GetPathFolder(util.ReplacePathSeparatorByOS(file.ExcelFile)))
+	err = xlsxFile.Save(folder + util.ReplacePathSeparatorByOS(file.ExcelFile))
+	if err != nil {
+		return errors.New("migration - ExportInstancesToExcel saving excel error. Debug: " + err.Error())
+	}
+	return nil
+}
+
+//hierarchyRows returns the path of each unit below the element, parents before their children
+func hierarchyRows(e *etree.Element, h hierarchy, path []string) [][]string {
+	rows := [][]string{}
+	for _, unit := range e.SelectElements(h.unit) {
+		unitPath := append(append([]string{}, path...), h.code(unit), h.name(unit))
+		rows = append(rows, unitPath)
+		rows = append(rows, hierarchyRows(unit, h, unitPath)...)
+	}
+	return rows
+}
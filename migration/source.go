@@ -0,0 +1,428 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/tealeg/xlsx"
+)
+
+//DataSource is the content of the excel or delimited text file used by the migrations. Each row is a slice with the text of its cells
+type DataSource struct {
+	File  string
+	Sheet string
+	Rows  [][]string
+
+	errors  []RowError
+	regexps map[string]*regexp.Regexp
+	dates   map[[2]int]time.Time
+}
+
+//windows1252 maps the bytes 0x80 to 0x9F, the only ones that differ from iso-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+var decoders = map[string]func([]byte) (string, error){
+	"utf-8":        decodeUTF8,
+	"utf8":         decodeUTF8,
+	"utf-16":       decodeUTF16(false),
+	"utf-16le":     decodeUTF16(false),
+	"utf-16be":     decodeUTF16(true),
+	"iso-8859-1":   decodeLatin1,
+	"latin1":       decodeLatin1,
+	"windows-1252": decodeWindows1252,
+	"cp1252":       decodeWindows1252,
+}
+
+//OpenDataSource reads the file defined in the excel attribute of the driver. Files with extension .xlsx or .xlsm are read from the sheet defined in the attribute sheet or the first one,
+//any other extension is read as delimited text (.csv, .tsv, .txt) using the attributes delimiter, quote and encoding
+func OpenDataSource(file *model.DriverFile) (*DataSource, error) {
+	path := util.ReplacePathSeparatorByOS(file.ExcelFile)
+	if err := checkExtension(file.ExcelFile); err != nil {
+		return nil, err
+	}
+	if isExcel(file.ExcelFile) {
+		return openExcel(path, file.Sheet)
+	}
+	return openDelimited(path, file)
+}
+
+//ValidateDataSource checks the delimiter, quote and encoding attributes used to read a delimited text file. They are ignored for excel files
+func ValidateDataSource(file *model.DriverFile) error {
+	if err := checkExtension(file.ExcelFile); err != nil {
+		return err
+	}
+	if isExcel(file.ExcelFile) {
+		return nil
+	}
+	_, _, _, err := delimitedFormat(file)
+	return err
+}
+
+//Value returns the text of the column, starting at 1, or an empty string if the row does not have it
+func Value(row []string, col int) string {
+	if col < 1 || col > len(row) {
+		return constant.Undefined
+	}
+	return row[col-1]
+}
+
+func isExcel(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".xlsx" || ext == ".xlsm"
+}
+
+//checkExtension returns an error for the binary spreadsheet formats that cannot be read, instead of reading them as delimited text
+func checkExtension(path string) error {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".xls", ".xlsb", ".ods":
+		return fmt.Errorf("excel format %s not supported, save the file %s as .xlsx or as delimited text", ext, path)
+	}
+	return nil
+}
+
+func openExcel(path, sheetName string) (*DataSource, error) {
+	xlFile, err := xlsx.OpenFile(path)
+	if err != nil {
+		return nil, errors.New("error opening excel. Debug: " + err.Error())
+	}
+	if len(xlFile.Sheets) == 0 {
+		return nil, errors.New("error opening excel. Debug: no sheet found in file " + path)
+	}
+	sheet, err := findSheet(xlFile, sheetName)
+	if err != nil {
+		return nil, err
+	}
+	source := &DataSource{File: path, Sheet: sheet.Name, Rows: make([][]string, len(sheet.Rows)), dates: map[[2]int]time.Time{}}
+	for i, row := range sheet.Rows {
+		if row == nil {
+			continue
+		}
+		values := make([]string, len(row.Cells))
+		for j, cell := range row.Cells {
+			values[j] = cell.String()
+			if cell.IsTime() {
+				if date, err := cell.GetTime(xlFile.Date1904); err == nil {
+					source.dates[[2]int{i, j}] = date
+				}
+			}
+		}
+		source.Rows[i] = values
+	}
+	return source, nil
+}
+
+//FormatDates replaces the text of the excel date cells, displayed with the number format of the cell, by the date in the layout.
+//Delimited text files have no date cells and are not changed
+func (s *DataSource) FormatDates(layout string) {
+	for cell, date := range s.dates {
+		s.Rows[cell[0]][cell[1]] = date.Format(layout)
+	}
+}
+
+//findSheet returns the sheet with the name, ignoring case, or the first sheet if no name is defined
+func findSheet(xlFile *xlsx.File, name string) (*xlsx.Sheet, error) {
+	if name == constant.Undefined {
+		return xlFile.Sheets[0], nil
+	}
+	names := make([]string, len(xlFile.Sheets))
+	for i, sheet := range xlFile.Sheets {
+		if strings.EqualFold(strings.TrimSpace(sheet.Name), strings.TrimSpace(name)) {
+			return sheet, nil
+		}
+		names[i] = sheet.Name
+	}
+	return nil, fmt.Errorf("sheet %s not found in excel, available sheets: %s", name, strings.Join(names, ", "))
+}
+
+//Matches returns a copy of the match tags of the driver with the col of the ones mapped by header name resolved.
+//The names are searched, ignoring case, in the row defined by the attribute headerRow or in the row before startRow
+func (s *DataSource) Matches(file *model.DriverFile) ([]model.MatchExcel, error) {
+	matches := make([]model.MatchExcel, len(file.MatchExcel))
+	copy(matches, file.MatchExcel)
+
+	var headers []string
+	headerRow := 0
+	for i, match := range matches {
+		err := s.validateConverters(match)
+		if err != nil {
+			return nil, err
+		}
+		if match.Header == constant.Undefined {
+			continue
+		}
+		if match.Col != 0 {
+			return nil, fmt.Errorf("match with header '%s' cannot define the attribute col", match.Header)
+		}
+		if headers == nil {
+			var err error
+			headerRow, err = headerRowNumber(file)
+			if err != nil {
+				return nil, err
+			}
+			if headerRow > len(s.Rows) {
+				return nil, fmt.Errorf("header row %d not found in sheet %s", headerRow, s.Sheet)
+			}
+			headers = s.Rows[headerRow-1]
+		}
+
+		col := 0
+		for index, header := range headers {
+			if !strings.EqualFold(strings.TrimSpace(header), strings.TrimSpace(match.Header)) {
+				continue
+			}
+			if col != 0 {
+				return nil, fmt.Errorf("header '%s' found in columns %d and %d of row %d in sheet %s", match.Header, col, index+1, headerRow, s.Sheet)
+			}
+			col = index + 1
+		}
+		if col == 0 {
+			return nil, fmt.Errorf("header '%s' not found in row %d of sheet %s", match.Header, headerRow, s.Sheet)
+		}
+		matches[i].Col = col
+	}
+	return matches, nil
+}
+
+func headerRowNumber(file *model.DriverFile) (int, error) {
+	if file.HeaderRow != constant.Undefined {
+		row, err := strconv.Atoi(file.HeaderRow)
+		if err != nil || row < 1 {
+			return 0, fmt.Errorf("tag 'headerRow' (%s) not a valid row number", file.HeaderRow)
+		}
+		return row, nil
+	}
+	startRow, err := strconv.Atoi(file.ExcelStartRow)
+	if err != nil || startRow < 2 {
+		return 0, errors.New("match by header requires the attribute headerRow or a startRow after the header row")
+	}
+	return startRow - 1, nil
+}
+
+func openDelimited(path string, file *model.DriverFile) (*DataSource, error) {
+	delimiter, quote, decode, err := delimitedFormat(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("error opening file. Debug: " + err.Error())
+	}
+	text, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding file %s. Debug: %s", path, err.Error())
+	}
+	rows, err := parseDelimited(text, delimiter, quote)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s. Debug: %s", path, err.Error())
+	}
+	return &DataSource{File: path, Sheet: filepath.Base(path), Rows: rows}, nil
+}
+
+//delimitedFormat returns the delimiter, the quote and the decoder defined in the driver. The delimiter is a tab for .tsv and .tab files and a comma for the others,
+//the quote is a double quote and the encoding is utf-8. Use quote="none" to read the quotes as part of the values
+func delimitedFormat(file *model.DriverFile) (rune, rune, func([]byte) (string, error), error) {
+	delimiter := ','
+	switch strings.ToLower(filepath.Ext(file.ExcelFile)) {
+	case ".tsv", ".tab":
+		delimiter = '\t'
+	}
+	if file.Delimiter != constant.Undefined {
+		r, err := singleRune("delimiter", file.Delimiter)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		delimiter = r
+	}
+
+	quote := '"'
+	switch strings.ToLower(file.Quote) {
+	case constant.Undefined:
+	case "none":
+		quote = 0
+	default:
+		r, err := singleRune("quote", file.Quote)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		quote = r
+	}
+	if quote == delimiter {
+		return 0, 0, nil, errors.New("attribute quote cannot be equal to the delimiter")
+	}
+	if delimiter == '\n' || delimiter == '\r' || quote == '\n' || quote == '\r' {
+		return 0, 0, nil, errors.New("attributes delimiter and quote cannot be a line break")
+	}
+
+	decode := decodeUTF8
+	if file.Encoding != constant.Undefined {
+		d, ok := decoders[strings.ToLower(file.Encoding)]
+		if !ok {
+			return 0, 0, nil, fmt.Errorf("invalid attribute encoding (%s), expected utf-8, utf-16, iso-8859-1 or windows-1252", file.Encoding)
+		}
+		decode = d
+	}
+	return delimiter, quote, decode, nil
+}
+
+func singleRune(attribute, value string) (rune, error) {
+	switch strings.ToLower(value) {
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("invalid attribute %s (%s), expected a single character or tab", attribute, value)
+	}
+	r, _ := utf8.DecodeRuneInString(value)
+	return r, nil
+}
+
+//parseDelimited splits the text into rows and values. Values starting with the quote can have delimiters, line breaks and doubled quotes inside.
+//Empty lines are kept as empty rows so the row numbers match the lines of the file, except the ones at the end
+func parseDelimited(text string, delimiter, quote rune) ([][]string, error) {
+	rows := [][]string{}
+	row := []string{}
+	value := strings.Builder{}
+	line := 1
+	quotedLine := 0
+	quoted := false
+	fieldStart := true
+	pending := false
+
+	endValue := func() {
+		row = append(row, value.String())
+		value.Reset()
+		fieldStart = true
+	}
+	endRow := func() {
+		if pending {
+			endValue()
+		}
+		rows = append(rows, row)
+		row = []string{}
+		pending = false
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if quoted {
+			if r == quote {
+				if i+1 < len(runes) && runes[i+1] == quote {
+					value.WriteRune(quote)
+					i++
+					continue
+				}
+				quoted = false
+				continue
+			}
+			if r == '\n' {
+				line++
+			}
+			value.WriteRune(r)
+			continue
+		}
+
+		switch {
+		case r == quote && quote != 0 && fieldStart:
+			quoted = true
+			quotedLine = line
+			fieldStart = false
+			pending = true
+		case r == delimiter:
+			endValue()
+			pending = true
+		case r == '\r' || r == '\n':
+			if r == '\r' && i+1 < len(runes) && runes[i+1] == '\n' {
+				i++
+			}
+			line++
+			endRow()
+		default:
+			value.WriteRune(r)
+			fieldStart = false
+			pending = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("quoted value starting at line %d is not closed", quotedLine)
+	}
+	if pending {
+		endRow()
+	}
+
+	last := len(rows)
+	for last > 0 && len(rows[last-1]) == 0 {
+		last--
+	}
+	return rows[:last], nil
+}
+
+func decodeUTF8(data []byte) (string, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	if !utf8.ValidString(text) {
+		return constant.Undefined, errors.New("invalid utf-8 content, define the attribute encoding")
+	}
+	return text, nil
+}
+
+//decodeUTF16 uses the byte order mark to define the byte order, without it the content is read as big or little endian as defined by the encoding
+func decodeUTF16(bigEndian bool) func([]byte) (string, error) {
+	return func(data []byte) (string, error) {
+		if len(data)%2 != 0 {
+			return constant.Undefined, errors.New("invalid utf-16 content, odd number of bytes")
+		}
+		order := bigEndian
+		if len(data) >= 2 {
+			switch {
+			case data[0] == 0xFE && data[1] == 0xFF:
+				order = true
+				data = data[2:]
+			case data[0] == 0xFF && data[1] == 0xFE:
+				order = false
+				data = data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if order {
+				units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+			} else {
+				units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+			}
+		}
+		return string(utf16.Decode(units)), nil
+	}
+}
+
+func decodeLatin1(data []byte) (string, error) {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes), nil
+}
+
+func decodeWindows1252(data []byte) (string, error) {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+		if b >= 0x80 && b <= 0x9F {
+			runes[i] = windows1252[b-0x80]
+		}
+	}
+	return string(runes), nil
+}
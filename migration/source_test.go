@@ -0,0 +1,260 @@
+package migration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/beevik/etree"
+	"github.com/tealeg/xlsx"
+)
+
+func TestReadDataFromExcelToReturnXMLResultFromDelimitedFiles(t *testing.T) {
+	expectedResult := etree.NewDocument()
+	expectedResult.ReadFromFile(packageMockFolder + "result.xml")
+	expectedResult.IndentTabs()
+	expectedResultString, _ := expectedResult.WriteToString()
+
+	for _, name := range []string{"data.csv", "data.tsv"} {
+		file := model.DriverFile{
+			Template:      packageMockFolder + "template.xml",
+			ExcelFile:     packageMockFolder + name,
+			InstanceTag:   "instance",
+			ExcelStartRow: "1",
+			MatchExcel: []model.MatchExcel{
+				{Col: 1, AttributeName: "instanceCode"},
+				{Col: 1, XPath: "//ColumnValue[@name='code']"},
+				{Col: 2, XPath: "//ColumnValue[@name='name']"},
+				{Col: 3, XPath: "//ColumnValue[@name='status_novo']", RemoveIfNull: true},
+				{Col: 4, XPath: "//ColumnValue[@name='multivalue_status']", MultiValued: true, Separator: ";"},
+				{Col: 5, XPath: "//ColumnValue[@name='analista']"},
+			},
+		}
+
+		result, err := ReadDataFromExcel(&file)
+		if err != nil {
+			t.Fatalf("Error reading data from %s to XOG file. Debug: %s", name, err.Error())
+		}
+		if result != expectedResultString {
+			t.Errorf("Error reading data from %s to XOG file. Debug: incorrect result", name)
+		}
+	}
+}
+
+func TestParseDelimitedToReturnRows(t *testing.T) {
+	tests := []struct {
+		text      string
+		delimiter rune
+		quote     rune
+		expected  [][]string
+	}{
+		{"a,b,c\nd,,f\n", ',', '"', [][]string{{"a", "b", "c"}, {"d", "", "f"}}},
+		{"\"a,1\",\"say \"\"hi\"\"\"\r\n\"line\nbreak\",x", ',', '"', [][]string{{"a,1", "say \"hi\""}, {"line\nbreak", "x"}}},
+		{"a;'b;c'\n\nd;e\n\n\n", ';', '\'', [][]string{{"a", "b;c"}, {}, {"d", "e"}}},
+		{"\"a\"\t\"b\"", '\t', 0, [][]string{{"\"a\"", "\"b\""}}},
+		{"a,", ',', '"', [][]string{{"a", ""}}},
+	}
+
+	for _, test := range tests {
+		rows, err := parseDelimited(test.text, test.delimiter, test.quote)
+		if err != nil {
+			t.Fatalf("Error parsing delimited text %q. Debug: %s", test.text, err.Error())
+		}
+		if !reflect.DeepEqual(rows, test.expected) {
+			t.Errorf("Error parsing delimited text %q. Expected %q got %q", test.text, test.expected, rows)
+		}
+	}
+
+	_, err := parseDelimited("a,\"b\nc", ',', '"')
+	if err == nil {
+		t.Errorf("Error parsing delimited text. Debug: not validating quoted value not closed")
+	}
+}
+
+func TestOpenDataSourceToDecodeEncodings(t *testing.T) {
+	folder, err := ioutil.TempDir("", "casxog_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	tests := []struct {
+		encoding string
+		data     []byte
+	}{
+		{"", []byte("\xef\xbb\xbfcode;nome\r\n1;Ação\r\n")},
+		{"iso-8859-1", []byte("code;nome\r\n1;A\xe7\xe3o\r\n")},
+		{"windows-1252", []byte("code;nome\r\n1;A\xe7\xe3o\r\n")},
+		{"utf-16", []byte("\xff\xfec\x00o\x00d\x00e\x00;\x00n\x00o\x00m\x00e\x00\n\x001\x00;\x00A\x00\xe7\x00\xe3\x00o\x00")},
+	}
+
+	for i, test := range tests {
+		path := filepath.Join(folder, "data"+string(rune('0'+i))+".txt")
+		ioutil.WriteFile(path, test.data, 0644)
+		file := model.DriverFile{ExcelFile: path, Delimiter: ";", Encoding: test.encoding}
+		source, err := OpenDataSource(&file)
+		if err != nil {
+			t.Fatalf("Error opening data source with encoding %s. Debug: %s", test.encoding, err.Error())
+		}
+		expected := [][]string{{"code", "nome"}, {"1", "Ação"}}
+		if !reflect.DeepEqual(source.Rows, expected) {
+			t.Errorf("Error opening data source with encoding %s. Expected %q got %q", test.encoding, expected, source.Rows)
+		}
+	}
+
+	path := filepath.Join(folder, "latin1.csv")
+	ioutil.WriteFile(path, []byte("A\xe7\xe3o"), 0644)
+	_, err = OpenDataSource(&model.DriverFile{ExcelFile: path})
+	if err == nil {
+		t.Errorf("Error opening data source. Debug: not validating invalid utf-8 content")
+	}
+}
+
+func TestValidateDataSourceToReturnErrorInvalidAttributes(t *testing.T) {
+	for _, file := range []model.DriverFile{
+		{ExcelFile: "data.csv", Delimiter: ";;"},
+		{ExcelFile: "data.csv", Quote: "''"},
+		{ExcelFile: "data.csv", Delimiter: ";", Quote: ";"},
+		{ExcelFile: "data.csv", Encoding: "ebcdic"},
+	} {
+		if ValidateDataSource(&file) == nil {
+			t.Errorf("Error validating data source attributes. Debug: accepted delimiter %q, quote %q and encoding %q", file.Delimiter, file.Quote, file.Encoding)
+		}
+	}
+
+	file := model.DriverFile{ExcelFile: "data.tsv", Delimiter: "tab", Quote: "none", Encoding: "UTF-8"}
+	if err := ValidateDataSource(&file); err != nil {
+		t.Errorf("Error validating data source attributes. Debug: %s", err.Error())
+	}
+}
+
+func TestOpenDataSourceToReadExcelExtensions(t *testing.T) {
+	folder, err := ioutil.TempDir("", "casxog_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	data, _ := ioutil.ReadFile(packageMockFolder + "data_sheets.xlsx")
+	path := filepath.Join(folder, "data_sheets.XLSM")
+	ioutil.WriteFile(path, data, 0644)
+
+	expected, err := OpenDataSource(&model.DriverFile{ExcelFile: packageMockFolder + "data_sheets.xlsx", Sheet: "instances"})
+	if err != nil {
+		t.Fatalf("Error opening excel data source. Debug: %s", err.Error())
+	}
+	source, err := OpenDataSource(&model.DriverFile{ExcelFile: path, Sheet: "instances"})
+	if err != nil {
+		t.Fatalf("Error opening .xlsm data source. Debug: %s", err.Error())
+	}
+	if !reflect.DeepEqual(source.Rows, expected.Rows) {
+		t.Errorf("Error opening .xlsm data source. Debug: rows different from the .xlsx file")
+	}
+
+	for _, ext := range []string{".xls", ".xlsb", ".ods"} {
+		file := model.DriverFile{ExcelFile: filepath.Join(folder, "data"+ext)}
+		if _, err := OpenDataSource(&file); err == nil {
+			t.Errorf("Error opening data source. Debug: binary format %s read as delimited text", ext)
+		}
+		if ValidateDataSource(&file) == nil {
+			t.Errorf("Error validating data source. Debug: binary format %s accepted", ext)
+		}
+	}
+}
+
+func TestDataSourceFormatDatesToFormatExcelDateCells(t *testing.T) {
+	folder, err := ioutil.TempDir("", "casxog_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	xlFile := xlsx.NewFile()
+	sheet, _ := xlFile.AddSheet("tasks")
+	row := sheet.AddRow()
+	row.AddCell().SetString("task_01")
+	row.AddCell().SetDateTime(time.Date(2019, 5, 10, 8, 30, 0, 0, time.UTC))
+	row.AddCell().SetInt(43595)
+	path := filepath.Join(folder, "tasks.xlsx")
+	if err := xlFile.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := OpenDataSource(&model.DriverFile{ExcelFile: path})
+	if err != nil {
+		t.Fatalf("Error opening excel data source. Debug: %s", err.Error())
+	}
+	source.FormatDates("2006-01-02T15:04:05")
+	expected := []string{"task_01", "2019-05-10T08:30:00", "43595"}
+	if !reflect.DeepEqual(source.Rows[0], expected) {
+		t.Errorf("Error formatting excel dates. Expected %v and received %v", expected, source.Rows[0])
+	}
+}
+
+func TestReadDataFromExcelToReturnXMLResultFromSheetAndHeaders(t *testing.T) {
+	file := model.DriverFile{
+		Template:      packageMockFolder + "template.xml",
+		ExcelFile:     packageMockFolder + "data_sheets.xlsx",
+		Sheet:         "instances",
+		InstanceTag:   "instance",
+		ExcelStartRow: "2",
+		MatchExcel: []model.MatchExcel{
+			{Header: "Code", AttributeName: "instanceCode"},
+			{Header: "code", XPath: "//ColumnValue[@name='code']"},
+			{Header: "Name", XPath: "//ColumnValue[@name='name']"},
+			{Header: "Status", XPath: "//ColumnValue[@name='status_novo']", RemoveIfNull: true},
+			{Header: " Multi Status ", XPath: "//ColumnValue[@name='multivalue_status']", MultiValued: true, Separator: ";"},
+			{Header: "Analyst", XPath: "//ColumnValue[@name='analista']"},
+		},
+	}
+
+	result, err := ReadDataFromExcel(&file)
+	if err != nil {
+		t.Fatalf("Error reading data from excel sheet to XOG file. Debug: %s", err.Error())
+	}
+
+	expectedResult := etree.NewDocument()
+	expectedResult.ReadFromFile(packageMockFolder + "result.xml")
+	expectedResult.IndentTabs()
+	expectedResultString, _ := expectedResult.WriteToString()
+	if result != expectedResultString {
+		t.Errorf("Error reading data from excel sheet to XOG file. Debug: incorrect result")
+	}
+	if file.MatchExcel[0].Col != 0 {
+		t.Errorf("Error reading data from excel sheet to XOG file. Debug: driver match changed when resolving the header")
+	}
+}
+
+func TestDataSourceMatchesToReturnError(t *testing.T) {
+	tests := []struct {
+		file     model.DriverFile
+		expected string
+	}{
+		{model.DriverFile{Sheet: "Tasks"}, "sheet Tasks not found in excel, available sheets: Info, Instances"},
+		{model.DriverFile{Sheet: "Instances", ExcelStartRow: "2", MatchExcel: []model.MatchExcel{{Header: "Project Manager"}}}, "header 'Project Manager' not found in row 1 of sheet Instances"},
+		{model.DriverFile{Sheet: "Instances", MatchExcel: []model.MatchExcel{{Header: "Code"}}}, "match by header requires the attribute headerRow or a startRow after the header row"},
+		{model.DriverFile{Sheet: "Instances", HeaderRow: "9", MatchExcel: []model.MatchExcel{{Header: "Code"}}}, "header row 9 not found in sheet Instances"},
+		{model.DriverFile{Sheet: "Instances", HeaderRow: "1", MatchExcel: []model.MatchExcel{{Header: "Code", Col: 2}}}, "match with header 'Code' cannot define the attribute col"},
+	}
+
+	for _, test := range tests {
+		test.file.ExcelFile = packageMockFolder + "data_sheets.xlsx"
+		source, err := OpenDataSource(&test.file)
+		if err == nil {
+			_, err = source.Matches(&test.file)
+		}
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Error resolving the match headers. Expected error %q and received %v", test.expected, err)
+		}
+	}
+
+	source := &DataSource{Sheet: "data.csv", Rows: [][]string{{"code", "name", "Code"}}}
+	_, err := source.Matches(&model.DriverFile{HeaderRow: "1", MatchExcel: []model.MatchExcel{{Header: "code"}}})
+	if err == nil || err.Error() != "header 'code' found in columns 1 and 3 of row 1 in sheet data.csv" {
+		t.Errorf("Error resolving the match headers. Debug: not validating duplicated headers, received %v", err)
+	}
+}
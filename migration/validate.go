@@ -0,0 +1,259 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/beevik/etree"
+	"github.com/tealeg/xlsx"
+)
+
+//dateLayouts are the formats accepted by the XOG for date attributes
+var dateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02"}
+
+var columnValueXPathRegexp = regexp.MustCompile(`ColumnValue\[@name=['"]([^'"]+)['"]\]`)
+
+//ObjectDefinition has the attributes of the target object used to validate the migration data before creating the xog
+type ObjectDefinition struct {
+	Code       string
+	Attributes map[string]*AttributeDefinition
+}
+
+//AttributeDefinition defines the data type, size, requirement and lookup of an object attribute
+type AttributeDefinition struct {
+	Code        string
+	DataType    string
+	MaxLength   int
+	Required    bool
+	Custom      bool
+	HasDefault  bool
+	MultiValued bool
+	LookupType  string
+	//LookupValues has the codes of a static lookup and if they are active. Nil when the values are not known, as in dynamic lookups
+	LookupValues map[string]bool
+}
+
+//NewObjectDefinition reads the custom attributes of the object from the xog read response of the type Objects
+func NewObjectDefinition(response *etree.Document, code string) (*ObjectDefinition, error) {
+	object := response.FindElement("//objects/object[@code='" + code + "']")
+	if object == nil {
+		return nil, fmt.Errorf("object %s not found in the target environment", code)
+	}
+
+	defaults := map[string]bool{}
+	for _, d := range object.SelectElements("attributeDefault") {
+		defaults[d.SelectAttrValue("code", constant.Undefined)] = true
+	}
+
+	definition := &ObjectDefinition{Code: code, Attributes: map[string]*AttributeDefinition{}}
+	for _, a := range object.SelectElements("customAttribute") {
+		attribute := &AttributeDefinition{
+			Code:        a.SelectAttrValue("code", constant.Undefined),
+			DataType:    a.SelectAttrValue("dataType", constant.Undefined),
+			Required:    a.SelectAttrValue("required", "false") == "true",
+			Custom:      a.SelectAttrValue("custom", "false") == "true",
+			MultiValued: a.SelectAttrValue("multiValued", "false") == "true",
+			LookupType:  a.SelectAttrValue("lookupType", constant.Undefined),
+		}
+		attribute.HasDefault = defaults[attribute.Code]
+		if attribute.DataType == "string" {
+			attribute.MaxLength, _ = strconv.Atoi(a.SelectAttrValue("dataSize", "0"))
+		}
+		definition.Attributes[attribute.Code] = attribute
+	}
+	return definition, nil
+}
+
+//LookupTypes returns the codes of the lookups used by the attributes
+func (o *ObjectDefinition) LookupTypes() []string {
+	found := map[string]bool{}
+	lookups := []string{}
+	for _, a := range o.Attributes {
+		if a.LookupType != constant.Undefined && !found[a.LookupType] {
+			found[a.LookupType] = true
+			lookups = append(lookups, a.LookupType)
+		}
+	}
+	sort.Strings(lookups)
+	return lookups
+}
+
+//SetLookup defines the values of the attributes that use the lookup from its xog read response. Only static lookups have their values validated
+func (o *ObjectDefinition) SetLookup(code string, response *etree.Document) {
+	lookup := response.FindElement("//staticLookup[@code='" + code + "']")
+	if lookup == nil {
+		return
+	}
+	values := map[string]bool{}
+	for _, v := range lookup.FindElements(".//lookupValue") {
+		values[v.SelectAttrValue("code", constant.Undefined)] = v.SelectAttrValue("status", "active") == "active"
+	}
+	for _, a := range o.Attributes {
+		if a.LookupType == code {
+			a.LookupValues = values
+		}
+	}
+}
+
+//ValidateData creates the xog as ReadDataFromExcel and validates the ColumnValue tags of each instance against the object definition.
+//The values that could not be converted and the invalid ones are returned as errors of the rows, sorted by row. The attributes of the instance
+//tag, mapped by the match attribute, are not checked and the empty required attributes are reported only if custom or in the template
+func ValidateData(file *model.DriverFile, object *ObjectDefinition) (string, []RowError, error) {
+	data, err := readData(file)
+	if err != nil {
+		return constant.Undefined, nil, err
+	}
+
+	columns := map[string]model.MatchExcel{}
+	for _, match := range data.matches {
+		if found := columnValueXPathRegexp.FindStringSubmatch(match.XPath); found != nil {
+			columns[found[1]] = match
+		}
+	}
+	templated := map[string]bool{}
+	for _, c := range data.template.FindElements(".//ColumnValue") {
+		templated[c.SelectAttrValue("name", constant.Undefined)] = true
+	}
+
+	rowErrors := append([]RowError{}, data.source.errors...)
+	for i, instance := range data.instances {
+		rowErrors = append(rowErrors, object.validateInstance(instance, data.source.Sheet, data.rows[i]+1, columns, templated)...)
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+
+	data.xog.IndentTabs()
+	str, err := data.xog.WriteToString()
+	return str, rowErrors, err
+}
+
+//validateInstance checks the values of the ColumnValue tags and the required custom attributes, or the ones in the template, that are empty
+func (o *ObjectDefinition) validateInstance(instance *etree.Element, sheet string, row int, columns map[string]model.MatchExcel, templated map[string]bool) []RowError {
+	rowErrors := []RowError{}
+	add := func(code, value, format string, args ...interface{}) {
+		e := RowError{Sheet: sheet, Row: row, Attribute: code, Value: value, Message: fmt.Sprintf(format, args...)}
+		if match, ok := columns[code]; ok {
+			e.Column = columnLabel(match)
+		}
+		rowErrors = append(rowErrors, e)
+	}
+
+	filled := map[string]bool{}
+	for _, c := range instance.FindElements(".//ColumnValue") {
+		code := c.SelectAttrValue("name", constant.Undefined)
+		attribute, ok := o.Attributes[code]
+		if !ok {
+			continue
+		}
+		values := []string{}
+		for _, v := range c.SelectElements("Value") {
+			values = append(values, v.Text())
+		}
+		if len(values) == 0 && strings.TrimSpace(c.Text()) != constant.Undefined {
+			values = append(values, c.Text())
+		}
+		if len(values) > 0 {
+			filled[code] = true
+		}
+		if len(values) > 1 && !attribute.MultiValued {
+			add(code, strings.Join(values, ";"), "attribute %s is not multi-valued", code)
+			continue
+		}
+		for _, value := range values {
+			if err := attribute.validate(value); err != nil {
+				add(code, value, "%s", err.Error())
+			}
+		}
+	}
+
+	codes := []string{}
+	for code := range o.Attributes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		attribute := o.Attributes[code]
+		if attribute.Required && !attribute.HasDefault && !filled[code] && (attribute.Custom || templated[code]) {
+			add(code, constant.Undefined, "required attribute %s is empty", code)
+		}
+	}
+	return rowErrors
+}
+
+//validate checks the value against the lookup values or the data type and maximum length of the attribute
+func (a *AttributeDefinition) validate(value string) error {
+	if a.LookupType != constant.Undefined {
+		if a.LookupValues == nil {
+			return nil
+		}
+		active, ok := a.LookupValues[value]
+		if !ok {
+			return fmt.Errorf("value %s not found in lookup %s", value, a.LookupType)
+		}
+		if !active {
+			return fmt.Errorf("value %s is inactive in lookup %s", value, a.LookupType)
+		}
+		return nil
+	}
+
+	switch a.DataType {
+	case "string":
+		if a.MaxLength > 0 && utf8.RuneCountInString(value) > a.MaxLength {
+			return fmt.Errorf("value has %d characters, attribute %s accepts %d", utf8.RuneCountInString(value), a.Code, a.MaxLength)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("value %s is not a number", value)
+		}
+	case "boolean":
+		switch value {
+		case "true", "false", "1", "0":
+		default:
+			return fmt.Errorf("value %s is not a boolean", value)
+		}
+	case "date":
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %s is not a date in the format yyyy-MM-ddTHH:mm:ss", value)
+	}
+	return nil
+}
+
+//ExportErrorsToExcel saves the errors to an excel file in the folder with one line for each invalid value
+func ExportErrorsToExcel(rowErrors []RowError, folder, path string) error {
+	xlsxFile := xlsx.NewFile()
+	sheet, _ := xlsxFile.AddSheet("Errors")
+	header := sheet.AddRow()
+	for _, v := range []string{"Sheet", "Row", "Column", "Attribute", "Value", "Error"} {
+		header.AddCell().Value = v
+	}
+	for _, e := range rowErrors {
+		row := sheet.AddRow()
+		row.AddCell().Value = e.Sheet
+		row.AddCell().SetInt(e.Row)
+		row.AddCell().Value = e.Column
+		row.AddCell().Value = e.Attribute
+		row.AddCell().Value = e.Value
+		row.AddCell().Value = e.Message
+	}
+
+	util.ValidateFolder(folder + util.GetPathFolder(path))
+	err := xlsxFile.Save(folder + path)
+	if err != nil {
+		return errors.New("migration - error saving the validation errors excel. Debug: " + err.Error())
+	}
+	return nil
+}
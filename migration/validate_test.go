@@ -0,0 +1,139 @@
+package migration
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/beevik/etree"
+	"github.com/tealeg/xlsx"
+)
+
+func loadObjectDefinitionMock(t *testing.T) *ObjectDefinition {
+	objectResponse := etree.NewDocument()
+	objectResponse.ReadFromFile(packageMockFolder + "object.xml")
+	object, err := NewObjectDefinition(objectResponse, "obj_sistema")
+	if err != nil {
+		t.Fatalf("Error reading object definition. Debug: %s", err.Error())
+	}
+	lookupResponse := etree.NewDocument()
+	lookupResponse.ReadFromFile(packageMockFolder + "lookup.xml")
+	for _, lookup := range object.LookupTypes() {
+		object.SetLookup(lookup, lookupResponse)
+	}
+	return object
+}
+
+func TestNewObjectDefinitionToReadAttributes(t *testing.T) {
+	object := loadObjectDefinitionMock(t)
+
+	if !reflect.DeepEqual(object.LookupTypes(), []string{"CAL_ACTIONITEM_STATUS", "OBJ_SISTEMA_STATUS"}) {
+		t.Errorf("Error reading object definition. Debug: invalid lookups %v", object.LookupTypes())
+	}
+	analista := object.Attributes["analista"]
+	if analista.DataType != "string" || analista.MaxLength != 20 || analista.Required {
+		t.Errorf("Error reading object definition. Debug: invalid attribute analista %+v", analista)
+	}
+	if !object.Attributes["budget"].HasDefault || !object.Attributes["status_novo"].Required {
+		t.Errorf("Error reading object definition. Debug: invalid required attributes")
+	}
+	if object.Attributes["status_novo"].LookupValues != nil {
+		t.Errorf("Error reading object definition. Debug: dynamic lookup with values")
+	}
+	expected := map[string]bool{"CAL_CLOSED": true, "CAL_DONE": true, "CAL_IN_PROGRESS": false}
+	if !reflect.DeepEqual(object.Attributes["multivalue_status"].LookupValues, expected) {
+		t.Errorf("Error reading object definition. Debug: invalid lookup values %v", object.Attributes["multivalue_status"].LookupValues)
+	}
+
+	_, err := NewObjectDefinition(etree.NewDocument(), "obj_sistema")
+	if err == nil {
+		t.Errorf("Error reading object definition. Debug: not validating object not found")
+	}
+}
+
+func TestValidateDataToReturnRowErrors(t *testing.T) {
+	object := loadObjectDefinitionMock(t)
+	file := model.DriverFile{
+		Template:      packageMockFolder + "template.xml",
+		ExcelFile:     packageMockFolder + "data.csv",
+		InstanceTag:   "instance",
+		ExcelStartRow: "1",
+		MatchExcel: []model.MatchExcel{
+			{Col: 1, AttributeName: "instanceCode"},
+			{Col: 1, XPath: "//ColumnValue[@name='code']"},
+			{Col: 2, XPath: "//ColumnValue[@name='name']"},
+			{Col: 3, XPath: "//ColumnValue[@name='status_novo']", RemoveIfNull: true},
+			{Col: 4, XPath: "//ColumnValue[@name='multivalue_status']", MultiValued: true, Separator: ";"},
+			{Col: 5, XPath: "//ColumnValue[@name='analista']"},
+		},
+	}
+
+	result, rowErrors, err := ValidateData(&file, object)
+	if err != nil {
+		t.Fatalf("Error validating migration data. Debug: %s", err.Error())
+	}
+	if result == "" {
+		t.Errorf("Error validating migration data. Debug: xog not returned")
+	}
+
+	expected := []string{
+		"sheet data.csv, row 1, column 4: value CAL_IN_PROGRESS is inactive in lookup CAL_ACTIONITEM_STATUS",
+		"sheet data.csv, row 2, column 3: required attribute status_novo is empty",
+		"sheet data.csv, row 3, column 4: value CAL_IN_PROGRESS is inactive in lookup CAL_ACTIONITEM_STATUS",
+	}
+	received := []string{}
+	for _, e := range rowErrors {
+		received = append(received, e.Error())
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Error validating migration data. Expected %q and received %q", expected, received)
+	}
+}
+
+func TestAttributeDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		attribute AttributeDefinition
+		value     string
+		valid     bool
+	}{
+		{AttributeDefinition{DataType: "string", MaxLength: 4}, "Ação", true},
+		{AttributeDefinition{DataType: "string", MaxLength: 4}, "Ações", false},
+		{AttributeDefinition{DataType: "number"}, "-12.5", true},
+		{AttributeDefinition{DataType: "number"}, "12,5", false},
+		{AttributeDefinition{DataType: "date"}, "2020-01-31T00:00:00", true},
+		{AttributeDefinition{DataType: "date"}, "2020-01-31", true},
+		{AttributeDefinition{DataType: "date"}, "31/01/2020", false},
+		{AttributeDefinition{DataType: "boolean"}, "1", true},
+		{AttributeDefinition{DataType: "boolean"}, "yes", false},
+		{AttributeDefinition{DataType: "string", LookupType: "DYNAMIC"}, "any", true},
+		{AttributeDefinition{DataType: "string", LookupType: "STATIC", LookupValues: map[string]bool{"A": true}}, "B", false},
+	}
+
+	for _, test := range tests {
+		err := test.attribute.validate(test.value)
+		if (err == nil) != test.valid {
+			t.Errorf("Error validating value %q for %+v. Expected valid %t and received %v", test.value, test.attribute, test.valid, err)
+		}
+	}
+}
+
+func TestExportErrorsToExcel(t *testing.T) {
+	folder := packageMockFolder + "_errors/"
+	defer os.RemoveAll(folder)
+
+	rowErrors := []RowError{{Sheet: "Instances", Row: 2, Column: "3 (Status)", Attribute: "status_novo", Message: "required attribute status_novo is empty"}}
+	err := ExportErrorsToExcel(rowErrors, folder, "migration/data_errors.xlsx")
+	if err != nil {
+		t.Fatalf("Error exporting validation errors to excel. Debug: %s", err.Error())
+	}
+
+	xlsxFile, err := xlsx.OpenFile(folder + "migration/data_errors.xlsx")
+	if err != nil {
+		t.Fatalf("Error exporting validation errors to excel. Debug: %s", err.Error())
+	}
+	rows := xlsxFile.Sheets[0].Rows
+	if len(rows) != 2 || rows[1].Cells[1].Value != "2" || rows[1].Cells[5].Value != rowErrors[0].Message {
+		t.Errorf("Error exporting validation errors to excel. Debug: invalid content")
+	}
+}
@@ -67,17 +67,43 @@ type FileReplace struct {
 	To   string `xml:"to"`
 }
 
-//MatchExcel defines the fields to map the cols on an excel file to attributes and data on the xog xml
+//MatchExcel defines the fields to map the cols on an excel file, by number or header name, to attributes and data on the xog xml
 type MatchExcel struct {
-	Col           int                     `xml:"col,attr"`
-	XPath         string                  `xml:"xpath,attr"`
-	AttributeName string                  `xml:"attribute,attr"`
-	MultiValued   bool                    `xml:"multiValued,attr"`
-	RemoveIfNull  bool                    `xml:"removeIfNull,attr"`
-	Separator     string                  `xml:"separator,attr"`
-	Element       string                  `xml:"element,attr"`
-	Attr          string                  `xml:"attr,attr"`
-	Attrs         []AttrMultiValueElement `xml:"attr"`
+	Col              int                     `xml:"col,attr"`
+	Header           string                  `xml:"header,attr"`
+	XPath            string                  `xml:"xpath,attr"`
+	AttributeName    string                  `xml:"attribute,attr"`
+	MultiValued      bool                    `xml:"multiValued,attr"`
+	RemoveIfNull     bool                    `xml:"removeIfNull,attr"`
+	Separator        string                  `xml:"separator,attr"`
+	Element          string                  `xml:"element,attr"`
+	Attr             string                  `xml:"attr,attr"`
+	Attrs            []AttrMultiValueElement `xml:"attr"`
+	Trim             bool                    `xml:"trim,attr"`
+	Case             string                  `xml:"case,attr"`
+	Default          string                  `xml:"default,attr"`
+	Regex            string                  `xml:"regex,attr"`
+	DateFormat       string                  `xml:"dateFormat,attr"`
+	DateOutput       string                  `xml:"dateOutput,attr"`
+	DecimalSeparator string                  `xml:"decimalSeparator,attr"`
+	TrueValues       string                  `xml:"trueValues,attr"`
+	FalseValues      string                  `xml:"falseValues,attr"`
+	BooleanOutput    string                  `xml:"booleanOutput,attr"`
+	Maps             []MatchMap              `xml:"map"`
+}
+
+//MatchMap defines a value of the excel and the code that replaces it, used to map lookup names to codes
+type MatchMap struct {
+	From string `xml:"from,attr"`
+	To   string `xml:"to,attr"`
+}
+
+//ExcelSheet defines an extra sheet of the exported excel with the sub elements found by the xpath or the custom attributes of each instance
+type ExcelSheet struct {
+	Name             string       `xml:"name,attr"`
+	XPath            string       `xml:"xpath,attr"`
+	CustomAttributes bool         `xml:"customAttributes,attr"`
+	MatchExcel       []MatchExcel `xml:"match"`
 }
 
 //AttrMultiValueElement defines the attributes to include in a multivalue
@@ -119,6 +145,12 @@ type DriverFile struct {
 	ExcelFile        string        `xml:"excel,attr"`
 	ExcelStartRow    string        `xml:"startRow,attr"`
 	ExcelEndRow      string        `xml:"endRow,attr"`
+	HeaderRow        string        `xml:"headerRow,attr"`
+	Sheet            string        `xml:"sheet,attr"`
+	Delimiter        string        `xml:"delimiter,attr"`
+	Quote            string        `xml:"quote,attr"`
+	Encoding         string        `xml:"encoding,attr"`
+	ValidateObject   string        `xml:"validateObject,attr"`
 	InstanceTag      string        `xml:"instance,attr"`
 	ExportToExcel    bool          `xml:"exportToExcel,attr"`
 	OnlyStructure    bool          `xml:"onlyStructure,attr"`
@@ -126,15 +158,19 @@ type DriverFile struct {
 	OnlyActive       bool          `xml:"onlyActive,attr"`
 	PackageTransform bool          `xml:"packageTransform,attr"`
 	InstancesPerFile int           `xml:"instancesPerFile,attr"`
+	Stream           bool          `xml:"stream,attr"`
 	Action           string        `xml:"action,attr"`
+	DependsOn        string        `xml:"dependsOn,attr"`
 	NSQL             string        `xml:"nsql"`
 	Sections         []Section     `xml:"section"`
 	Elements         []Element     `xml:"element"`
 	Replace          []FileReplace `xml:"replace"`
 	MatchExcel       []MatchExcel  `xml:"match"`
+	Sheets           []ExcelSheet  `xml:"sheet"`
 	Filters          []Filter      `xml:"filter"`
 	HeaderArgs       []HeaderArg   `xml:"args"`
 	ExecutionOrder   int
+	Barrier          bool
 	xogXML           string
 	auxXML           string
 }
@@ -316,8 +352,7 @@ func (d *DriverFile) GetXMLType() string {
 }
 
 func executeSoapCall(body string, env *EnvType, soapFunc util.Soap) (string, error) {
-	bodyWithSession := strings.Replace(body, "<xog:SessionID/>", "<xog:SessionID>"+env.Session+"</xog:SessionID>", -1)
-	return soapFunc(bodyWithSession, env.URL, env.Proxy)
+	return sessionSoap(body, env, soapFunc)
 }
 
 func getAuxDriverFile(d *DriverFile) *DriverFile {
@@ -525,6 +560,9 @@ type Driver struct {
 	Info           os.FileInfo
 	Folder         string
 	AutomaticWrite bool
+	Concurrency    int
+	Backup         bool
+	XML            []byte
 }
 
 //Clear reset the contents of the driver
@@ -534,6 +572,9 @@ func (d *Driver) Clear() {
 	d.PackageDriver = false
 	d.FilePath = constant.Undefined
 	d.Info = nil
+	d.Concurrency = 0
+	d.Backup = false
+	d.XML = nil
 }
 
 //MaxTypeNameLen returns the largest size, number of characters in the type name, from the driver's list
@@ -548,6 +589,26 @@ func (d *Driver) MaxTypeNameLen() int {
 	return max
 }
 
+//Dependencies returns the indexes of the files that must be processed before the file in the index. A file depends on every file before the last barrier preceding it,
+//on the files with the path defined in its dependsOn attribute and on the files with its own path, that would use the same read, write and debug files
+func (d *Driver) Dependencies(index int) []int {
+	stageStart := 0
+	for i := index; i > 0; i-- {
+		if d.Files[i].Barrier {
+			stageStart = i
+			break
+		}
+	}
+
+	dependencies := []int{}
+	for i := 0; i < index; i++ {
+		if i < stageStart || d.Files[i].Path == d.Files[index].Path || (d.Files[index].DependsOn != constant.Undefined && d.Files[i].Path == d.Files[index].DependsOn) {
+			dependencies = append(dependencies, i)
+		}
+	}
+	return dependencies
+}
+
 //ByExecutionOrder used to order the drivers according to the users defined sequence
 type ByExecutionOrder []DriverFile
 
@@ -559,6 +620,8 @@ func (d ByExecutionOrder) Less(i, j int) bool { return d[i].ExecutionOrder < d[j
 type DriverTypesPattern struct {
 	Version                   string       `xml:"version,attr"`
 	AutomaticWrite            bool         `xml:"autoWrite,attr"`
+	Concurrency               int          `xml:"concurrency,attr"`
+	Backup                    bool         `xml:"backup,attr"`
 	Files                     []DriverFile `xml:"file"`
 	Objects                   []DriverFile `xml:"object"`
 	Views                     []DriverFile `xml:"view"`
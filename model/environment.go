@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"strings"
+	"sync"
 
+	"github.com/andreluzz/cas-xog/secret"
 	"github.com/andreluzz/cas-xog/util"
 	"github.com/beevik/etree"
 )
@@ -24,22 +28,45 @@ func LoadEnvironmentsList(path string) (*Environments, error) {
 	xml.Unmarshal(xmlFile, environments)
 	environments.Source = &EnvType{}
 	environments.Target = &EnvType{}
+
+	for _, e := range environments.Available {
+		if e.Retry != nil {
+			if err := e.Retry.validate(); err != nil {
+				return nil, errors.New("Error loading environment " + e.Name + " - " + err.Error())
+			}
+		}
+		if e.Transport != nil {
+			if err := e.Transport.validate(); err != nil {
+				return nil, errors.New("Error loading environment " + e.Name + " - " + err.Error())
+			}
+		}
+	}
 	return environments, err
 }
 
 //EnvType defines an environment attributes
 type EnvType struct {
-	Name         string         `xml:"name,attr"`
-	URL          string         `xml:"endpoint"`
-	Username     string         `xml:"username"`
-	Password     string         `xml:"password"`
-	Proxy        string         `xml:"proxy"`
-	Cookie       string         `xml:"cookie"`
-	API          apiEnvironment `xml:"api"`
+	Name         string             `xml:"name,attr"`
+	URL          string             `xml:"endpoint"`
+	Username     string             `xml:"username"`
+	Password     string             `xml:"password"`
+	Proxy        string             `xml:"proxy"`
+	Cookie       string             `xml:"cookie"`
+	API          apiEnvironment     `xml:"api"`
+	Retry        *RetryPolicy       `xml:"retry"`
+	Transport    *TransportSettings `xml:"transport"`
+	Vars         []Variable         `xml:"vars>var"`
 	Session      string
 	AuthToken    string
 	Copy         bool
 	RequestLogin bool
+	sessionMutex sync.Mutex
+}
+
+//Variable defines a value used to replace the placeholder ${name} in the drivers
+type Variable struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 type apiEnvironment struct {
@@ -48,20 +75,36 @@ type apiEnvironment struct {
 	Context string `xml:"context,attr"`
 }
 
-//Init loads a specific environment from user environments list
-func (e *EnvType) Init(envIndex int) {
+//Init loads a specific environment from user environments list. The credentials defined as env:NAME or encrypted are resolved to plain text
+func (e *EnvType) Init(envIndex int) error {
 	available := environments.Available[envIndex].copyEnv()
 
+	username, err := secret.Resolve(available.Username)
+	if err != nil {
+		return fmt.Errorf("invalid username of environment %s - %s", available.Name, err.Error())
+	}
+	password, err := secret.Resolve(available.Password)
+	if err != nil {
+		return fmt.Errorf("invalid password of environment %s - %s", available.Name, err.Error())
+	}
+	token, err := secret.Resolve(strings.TrimSpace(available.API.Token))
+	if err != nil {
+		return fmt.Errorf("invalid api token of environment %s - %s", available.Name, err.Error())
+	}
+
 	e.Name = available.Name
-	e.Username = available.Username
-	e.Password = available.Password
+	e.Username = username
+	e.Password = password
 	e.URL = available.URL
 	e.Proxy = available.Proxy
 	e.Cookie = available.Cookie
+	e.Retry = available.Retry
+	e.Transport = available.Transport
+	e.Vars = available.Vars
 	e.RequestLogin = false
 	e.API.Client = available.API.Client
 	e.API.Context = available.API.Context
-	e.API.Token = available.API.Token
+	e.API.Token = token
 
 	if available.API.Context == "" {
 		e.API.Context = "/ppm"
@@ -70,6 +113,11 @@ func (e *EnvType) Init(envIndex int) {
 	if e.Username == "" || e.Password == "" {
 		e.RequestLogin = true
 	}
+
+	if e.Transport != nil {
+		return e.Transport.register(e)
+	}
+	return nil
 }
 
 //Login executes an soap call to retrieve the session id from the environment
@@ -110,14 +158,17 @@ func (e *EnvType) logout(soapFunc util.Soap) error {
 
 func (e *EnvType) copyEnv() *EnvType {
 	ne := &EnvType{
-		Name:     e.Name,
-		Username: e.Username,
-		Password: e.Password,
-		URL:      e.URL,
-		Session:  e.Session,
-		Proxy:    e.Proxy,
-		Cookie:   e.Cookie,
-		Copy:     true,
+		Name:      e.Name,
+		Username:  e.Username,
+		Password:  e.Password,
+		URL:       e.URL,
+		Session:   e.Session,
+		Proxy:     e.Proxy,
+		Cookie:    e.Cookie,
+		Retry:     e.Retry,
+		Vars:      e.Vars,
+		Transport: e.Transport,
+		Copy:      true,
 		API: apiEnvironment{
 			Token:   e.API.Token,
 			Client:  e.API.Client,
@@ -134,6 +185,9 @@ func (e *EnvType) clear() error {
 	e.Session = ""
 	e.Proxy = ""
 	e.Cookie = ""
+	e.Retry = nil
+	e.Transport = nil
+	e.Vars = nil
 	e.Copy = false
 	return nil
 }
@@ -145,6 +199,37 @@ type Environments struct {
 	Source    *EnvType
 }
 
+//IndexByName returns the index of the available environment with the defined name or -1 if it does not exist
+func (e *Environments) IndexByName(name string) int {
+	for i, env := range e.Available {
+		if strings.EqualFold(env.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+//Variable returns the value of the variable defined in the environment
+func (e *EnvType) Variable(name string) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	for _, v := range e.Vars {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+//GetAvailableEnvironments returns the environments loaded from the environments file
+func GetAvailableEnvironments() []*EnvType {
+	if environments == nil {
+		return nil
+	}
+	return environments.Available
+}
+
 //CopyTargetFromSource copy the data from source to target environment
 func (e *Environments) CopyTargetFromSource() {
 	e.Target = e.Source.copyEnv()
@@ -197,7 +282,7 @@ func login(env *EnvType, soapFunc util.Soap) (string, error) {
 		return "", errors.New("Problems getting login xml: " + err.Error())
 	}
 
-	response, err := soapFunc(body, env.URL, env.Proxy)
+	response, err := env.Retry.Soap(soapFunc, env.Name)(body, env.URL, env.Proxy)
 	resp := etree.NewDocument()
 	resp.ReadFromString(response)
 
@@ -2,6 +2,15 @@ package model
 
 //Output defines the attributes to represent transformations results
 type Output struct {
-	Code  string
-	Debug string
+	Code       string
+	Debug      string
+	Statistics *OutputStatistics
+}
+
+//OutputStatistics defines the records statistics returned by the xog output
+type OutputStatistics struct {
+	TotalNumberOfRecords int `json:"totalNumberOfRecords"`
+	InsertedRecords      int `json:"insertedRecords"`
+	UpdatedRecords       int `json:"updatedRecords"`
+	FailureRecords       int `json:"failureRecords"`
 }
@@ -1,26 +1,29 @@
 package model
 
+import "encoding/xml"
+
 //Definition defines the attributes to load the version definitions xml tag
 type Definition struct {
 	Action         string `xml:"action,attr"`
 	Description    string `xml:"description,attr"`
-	Default        string `xml:"default,attr"`
-	TransformTypes string `xml:"transformTypes"`
-	From           string `xml:"from"`
-	To             string `xml:"to"`
-	Value          string
+	Default        string `xml:"default,attr,omitempty"`
+	TransformTypes string `xml:"transformTypes,omitempty"`
+	From           string `xml:"from,omitempty"`
+	To             string `xml:"to,omitempty"`
+	Value          string `xml:"-"`
 }
 
 //Version defines the attributes to load the package versions xml tag
 type Version struct {
 	Name           string       `xml:"name,attr"`
 	Folder         string       `xml:"folder,attr"`
-	DriverFileName string       `xml:"driver,attr"`
+	DriverFileName string       `xml:"driver,attr,omitempty"`
 	Definitions    []Definition `xml:"definition"`
 }
 
 //Package defines the attributes to load the package xml file
 type Package struct {
+	XMLName        xml.Name  `xml:"package"`
 	Name           string    `xml:"name,attr"`
 	Folder         string    `xml:"folder,attr"`
 	DriverFileName string    `xml:"driver,attr"`
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+const (
+	defaultRetryBackoff     = time.Second
+	defaultRetryMaxBackoff  = 30 * time.Second
+	defaultRetryStatusCodes = "502,503,504"
+	defaultRetryErrors      = "connection reset,connection refused,timeout,EOF,broken pipe"
+	//statuses returned before the request is processed, the only ones safe to retry for a non-idempotent POST
+	safePostStatusCodes = "429,503"
+)
+
+var sleep = time.Sleep
+
+//RetryPolicy defines how the requests to an environment are retried after a transient failure
+type RetryPolicy struct {
+	Attempts    int    `xml:"attempts,attr"`
+	Backoff     string `xml:"backoff,attr"`
+	MaxBackoff  string `xml:"maxBackoff,attr"`
+	StatusCodes string `xml:"statusCodes,attr"`
+	Errors      string `xml:"errors,attr"`
+}
+
+func (r *RetryPolicy) validate() error {
+	if r.Attempts < 0 {
+		return fmt.Errorf("invalid retry attempts %d", r.Attempts)
+	}
+	for _, d := range []string{r.Backoff, r.MaxBackoff} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid retry duration %s, use values like 500ms, 2s or 1m", d)
+		}
+	}
+	for _, c := range splitList(r.StatusCodes) {
+		if _, err := strconv.Atoi(c); err != nil {
+			return fmt.Errorf("invalid retry status code %s", c)
+		}
+	}
+	return nil
+}
+
+//delay returns the exponential backoff to wait before the attempt, starting with the first retry as attempt 1
+func (r *RetryPolicy) delay(attempt int) time.Duration {
+	backoff := parseDuration(r.Backoff, defaultRetryBackoff)
+	maxBackoff := parseDuration(r.MaxBackoff, defaultRetryMaxBackoff)
+	delay := backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+func (r *RetryPolicy) retryableStatus(status int, idempotent bool) bool {
+	codes := r.StatusCodes
+	if codes == "" {
+		codes = defaultRetryStatusCodes
+	}
+	if !containsStatus(codes, status) {
+		return false
+	}
+	return idempotent || containsStatus(safePostStatusCodes, status)
+}
+
+func (r *RetryPolicy) retryableError(err error, idempotent bool) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *util.StatusError
+	if errors.As(err, &statusErr) {
+		return r.retryableStatus(statusErr.StatusCode, idempotent)
+	}
+	if !idempotent {
+		//a non-idempotent request can only be repeated safely when the connection was never established
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	list := r.Errors
+	if list == "" {
+		list = defaultRetryErrors
+	}
+	for _, e := range splitList(list) {
+		if strings.Contains(strings.ToLower(err.Error()), strings.ToLower(e)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RetryPolicy) enabled() bool {
+	return r != nil && r.Attempts > 1
+}
+
+func (r *RetryPolicy) wait(attempt int, envName, debug string) {
+	delay := r.delay(attempt)
+	log.Warn("\n[CAS-XOG][yellow[Retry]] %d/%d in %s - Environment: %s | Debug: %s", attempt, r.Attempts-1, delay, envName, debug)
+	sleep(delay)
+}
+
+//Soap returns a soap function that retries the requests failed with a retryable error. Xog requests are retried as idempotent
+func (r *RetryPolicy) Soap(soapFunc util.Soap, envName string) util.Soap {
+	if !r.enabled() {
+		return soapFunc
+	}
+	return func(request, endpoint, proxy string) (string, error) {
+		response, err := soapFunc(request, endpoint, proxy)
+		for attempt := 1; attempt < r.Attempts && r.retryableError(err, true); attempt++ {
+			r.wait(attempt, envName, err.Error())
+			response, err = soapFunc(request, endpoint, proxy)
+		}
+		return response, err
+	}
+}
+
+//Rest returns a rest function that retries the requests failed with a retryable error or status code. POST requests are retried only when the environment did not process them
+func (r *RetryPolicy) Rest(restFunc util.Rest, envName string) util.Rest {
+	if !r.enabled() {
+		return restFunc
+	}
+	return func(jsonString []byte, config util.APIConfig, params map[string]string) ([]byte, int, error) {
+		idempotent := config.Method != http.MethodPost
+		response, status, err := restFunc(jsonString, config, params)
+		for attempt := 1; attempt < r.Attempts; attempt++ {
+			debug := ""
+			if err != nil && r.retryableError(err, idempotent) {
+				debug = err.Error()
+			} else if err == nil && r.retryableStatus(status, idempotent) {
+				debug = fmt.Sprintf("status code: %d | url: %s", status, config.Endpoint)
+			} else {
+				break
+			}
+			r.wait(attempt, envName, debug)
+			response, status, err = restFunc(jsonString, config, params)
+		}
+		return response, status, err
+	}
+}
+
+func parseDuration(value string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+func splitList(list string) []string {
+	values := []string{}
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func containsStatus(codes string, status int) bool {
+	for _, c := range splitList(codes) {
+		if c == strconv.Itoa(status) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//sessionExpiredRegexp matches the soap faults returned when the session id is no longer valid
+var sessionExpiredRegexp = regexp.MustCompile(`(?is)<(\w+:)?Fault>.*(invalid session|session (id )?(is )?(invalid|expired|not valid)|session has expired|session timed out|not logged in).*</(\w+:)?Fault>`)
+
+//sessionExpired validates if the soap call failed because the session id expired
+func sessionExpired(response string, err error) bool {
+	var statusErr *util.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized
+	}
+	return err == nil && sessionExpiredRegexp.MatchString(response)
+}
+
+//relogin replaces the session id of the environment if it is still the expired one. Concurrent calls that failed with the same session log in only once
+func (e *EnvType) relogin(expiredSession string, soapFunc util.Soap) error {
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+	if e.Session != expiredSession {
+		return nil
+	}
+	if e.Username == "" || e.Password == "" {
+		return errors.New("session expired and there are no credentials to login again in environment: " + e.Name)
+	}
+	session, err := login(e, soapFunc)
+	if err != nil {
+		return err
+	}
+	e.Session = session
+	log.Warn("\n[CAS-XOG][yellow[Re-login]] - Environment: %s | Debug: xog session expired\n", e.Name)
+	return nil
+}
+
+//reloginAPI replaces the rest api token of the environment if it is still the expired one
+func (e *EnvType) reloginAPI(expiredToken string) error {
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+	if e.AuthToken != expiredToken {
+		return nil
+	}
+	token, err := loginAPI(e)
+	if err != nil {
+		return err
+	}
+	e.AuthToken = token
+	log.Warn("\n[CAS-XOG][yellow[Re-login]] - Environment: %s | Debug: rest api token expired\n", e.Name)
+	return nil
+}
+
+//SessionRest returns a rest function that logs in again and repeats the request once when the environment answers 401.
+//Only requests using the token from the login are repeated, a token defined in the environment tag api cannot be renewed
+func (e *EnvType) SessionRest(restFunc util.Rest) util.Rest {
+	return func(jsonString []byte, config util.APIConfig, params map[string]string) ([]byte, int, error) {
+		response, status, err := restFunc(jsonString, config, params)
+		if e.AuthToken == "" || config.Token != e.AuthToken || !restUnauthorized(status, err) {
+			return response, status, err
+		}
+		loginErr := e.reloginAPI(config.Token)
+		if loginErr != nil {
+			log.Error("\n[CAS-XOG][red[Re-login failed]] - Environment: %s | Debug: %s\n", e.Name, loginErr.Error())
+			return response, status, err
+		}
+		config.Token = e.AuthToken
+		return restFunc(jsonString, config, params)
+	}
+}
+
+func restUnauthorized(status int, err error) bool {
+	var statusErr *util.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized
+	}
+	return err == nil && status == http.StatusUnauthorized
+}
+
+//sessionSoap executes the soap call with the current session id of the environment. If the session expired it logs in again and repeats the call once
+func sessionSoap(body string, env *EnvType, soapFunc util.Soap) (string, error) {
+	session := env.currentSession()
+	log.Trace("soap request - environment: %s | body: %s", env.Name, body)
+	response, err := env.Retry.Soap(soapFunc, env.Name)(withSession(body, session), env.URL, env.Proxy)
+	log.Trace("soap response - environment: %s | body: %s", env.Name, response)
+	if session == "" || !sessionExpired(response, err) {
+		return response, err
+	}
+	loginErr := env.relogin(session, soapFunc)
+	if loginErr != nil {
+		log.Error("\n[CAS-XOG][red[Re-login failed]] - Environment: %s | Debug: %s\n", env.Name, loginErr.Error())
+		return response, err
+	}
+	return env.Retry.Soap(soapFunc, env.Name)(withSession(body, env.currentSession()), env.URL, env.Proxy)
+}
+
+func (e *EnvType) currentSession() string {
+	e.sessionMutex.Lock()
+	defer e.sessionMutex.Unlock()
+	return e.Session
+}
+
+func withSession(body, session string) string {
+	return strings.Replace(body, "<xog:SessionID/>", "<xog:SessionID>"+session+"</xog:SessionID>", -1)
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//maxInspectedResponse is the size of the streamed responses returned to the session and retry policy, large enough for any soap fault
+const maxInspectedResponse = 64 * 1024
+
+//RunXogXMLToFile executes a soap call to the principal xog xml writing the response to path instead of keeping it in memory.
+//The session and retry policy of the environment are applied as in RunXogXML
+func (d *DriverFile) RunXogXMLToFile(env *EnvType, stream util.SoapStream, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	soapFunc := func(request, endpoint, proxy string) (string, error) {
+		err := file.Truncate(0)
+		if err != nil {
+			return "", err
+		}
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", err
+		}
+		err = stream(request, endpoint, proxy, file)
+		if err != nil {
+			return "", err
+		}
+		return inspectResponse(file)
+	}
+
+	_, err = executeSoapCall(d.xogXML, env, soapFunc)
+	return err
+}
+
+//inspectResponse returns the content of small responses so the soap faults can be found, large responses are returned empty
+func inspectResponse(file *os.File) (string, error) {
+	info, err := file.Stat()
+	if err != nil || info.Size() > maxInspectedResponse {
+		return "", err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadAll(file)
+	return string(data), err
+}
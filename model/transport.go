@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/secret"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//TransportSettings defines the TLS, proxy credentials and timeouts of the connections to an environment
+type TransportSettings struct {
+	CAFile             string `xml:"caFile,attr"`
+	CertFile           string `xml:"certFile,attr"`
+	KeyFile            string `xml:"keyFile,attr"`
+	MinTLSVersion      string `xml:"minTLSVersion,attr"`
+	InsecureSkipVerify bool   `xml:"insecureSkipVerify,attr"`
+	ProxyUsername      string `xml:"proxyUsername,attr"`
+	ProxyPassword      string `xml:"proxyPassword,attr"`
+	SoapTimeout        string `xml:"soapTimeout,attr"`
+	RestTimeout        string `xml:"restTimeout,attr"`
+}
+
+func (t *TransportSettings) validate() error {
+	for _, d := range []string{t.SoapTimeout, t.RestTimeout} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid transport timeout %s, use values like 90s, 5m or 1h", d)
+		}
+	}
+	if t.MinTLSVersion != "" && !util.ValidTLSVersion(t.MinTLSVersion) {
+		return fmt.Errorf("invalid transport minTLSVersion %s, use 1.0, 1.1, 1.2 or 1.3", t.MinTLSVersion)
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("transport client certificate requires both certFile and keyFile")
+	}
+	return nil
+}
+
+//register creates the transport used by the requests to the environment, resolving the proxy credentials defined as env:NAME or encrypted
+func (t *TransportSettings) register(env *EnvType) error {
+	username, err := secret.Resolve(t.ProxyUsername)
+	if err != nil {
+		return fmt.Errorf("invalid proxy username of environment %s - %s", env.Name, err.Error())
+	}
+	password, err := secret.Resolve(t.ProxyPassword)
+	if err != nil {
+		return fmt.Errorf("invalid proxy password of environment %s - %s", env.Name, err.Error())
+	}
+
+	err = util.RegisterTransport(env.URL, util.TransportConfig{
+		Proxy:              env.Proxy,
+		ProxyUsername:      username,
+		ProxyPassword:      password,
+		CAFile:             t.CAFile,
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		MinTLSVersion:      t.MinTLSVersion,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		SoapTimeout:        parseDuration(t.SoapTimeout, util.DefaultSoapTimeout),
+		RestTimeout:        parseDuration(t.RestTimeout, util.DefaultRestTimeout),
+	})
+	if err != nil {
+		return fmt.Errorf("invalid transport of environment %s - %s", env.Name, err.Error())
+	}
+	if t.InsecureSkipVerify {
+		log.Warn("\n[CAS-XOG][yellow[Warning]]: TLS certificate verification disabled for environment %s\n", env.Name)
+	}
+	return nil
+}
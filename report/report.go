@@ -0,0 +1,208 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//Entry defines the result of a single driver file processed
+type Entry struct {
+	Type       string                  `json:"type"`
+	Path       string                  `json:"path"`
+	Action     string                  `json:"action"`
+	Code       string                  `json:"code"`
+	Debug      string                  `json:"debug"`
+	Elapsed    float64                 `json:"elapsedSeconds"`
+	Statistics *model.OutputStatistics `json:"statistics,omitempty"`
+}
+
+//Suite defines the results of one execution of a driver
+type Suite struct {
+	Name    string         `json:"name"`
+	Driver  string         `json:"driver"`
+	Action  string         `json:"action"`
+	Source  string         `json:"source,omitempty"`
+	Target  string         `json:"target,omitempty"`
+	Started time.Time      `json:"started"`
+	Elapsed float64        `json:"elapsedSeconds"`
+	Results map[string]int `json:"results"`
+	Entries []Entry        `json:"entries"`
+}
+
+//Report defines the structured result of all driver executions from a run
+type Report struct {
+	Suites []*Suite `json:"suites"`
+}
+
+//NewSuite creates an empty suite to register the driver files processed
+func NewSuite(name, driver, action string, environments *model.Environments) *Suite {
+	suite := &Suite{
+		Name:    name,
+		Driver:  driver,
+		Action:  action,
+		Started: time.Now(),
+		Results: map[string]int{constant.OutputSuccess: 0, constant.OutputWarning: 0, constant.OutputError: 0, constant.OutputIgnored: 0},
+		Entries: []Entry{},
+	}
+	if environments != nil {
+		if environments.Source != nil {
+			suite.Source = environments.Source.Name
+		}
+		if environments.Target != nil {
+			suite.Target = environments.Target.Name
+		}
+	}
+	return suite
+}
+
+//Add registers the output of a processed driver file
+func (s *Suite) Add(xmlType, path string, output model.Output, elapsed time.Duration) {
+	s.Results[output.Code]++
+	s.Entries = append(s.Entries, Entry{
+		Type:       xmlType,
+		Path:       path,
+		Action:     s.Action,
+		Code:       output.Code,
+		Debug:      output.Debug,
+		Elapsed:    elapsed.Seconds(),
+		Statistics: output.Statistics,
+	})
+}
+
+//Finish registers the total elapsed time of the suite
+func (s *Suite) Finish() {
+	s.Elapsed = time.Since(s.Started).Seconds()
+}
+
+//Add includes the suites in the report
+func (r *Report) Add(suites ...*Suite) {
+	for _, s := range suites {
+		if s != nil {
+			r.Suites = append(r.Suites, s)
+		}
+	}
+}
+
+//Results returns the total of files by output code from all suites
+func (r *Report) Results() map[string]int {
+	results := map[string]int{constant.OutputSuccess: 0, constant.OutputWarning: 0, constant.OutputError: 0, constant.OutputIgnored: 0}
+	for _, s := range r.Suites {
+		for code, total := range s.Results {
+			results[code] += total
+		}
+	}
+	return results
+}
+
+//Save writes the report as JSON and JUnit XML using the path without extension
+func (r *Report) Save(path string) error {
+	util.ValidateFolder(filepath.Dir(path))
+
+	data, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+".json", data, 0644)
+	if err != nil {
+		return fmt.Errorf("error saving json report. Debug: %s", err.Error())
+	}
+
+	data, err = r.junit()
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+".xml", data, 0644)
+	if err != nil {
+		return fmt.Errorf("error saving junit report. Debug: %s", err.Error())
+	}
+	return nil
+}
+
+//DefaultPath returns the path, without extension, used to save a report created now
+func DefaultPath() string {
+	return constant.FolderReport + "cas-xog_" + time.Now().Format("20060102_150405")
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+func (r *Report) junit() ([]byte, error) {
+	suites := junitTestSuites{}
+	elapsed := 0.0
+	for _, s := range r.Suites {
+		suite := junitTestSuite{
+			Name:      s.Name,
+			Tests:     len(s.Entries),
+			Time:      fmt.Sprintf("%.3f", s.Elapsed),
+			Timestamp: s.Started.Format("2006-01-02T15:04:05"),
+		}
+		for _, e := range s.Entries {
+			testCase := junitTestCase{
+				Name:      e.Path,
+				ClassName: e.Type,
+				Time:      fmt.Sprintf("%.3f", e.Elapsed),
+			}
+			switch e.Code {
+			case constant.OutputError:
+				testCase.Failure = &junitMessage{Message: e.Debug, Type: constant.OutputError}
+				suite.Failures++
+			case constant.OutputIgnored:
+				testCase.Skipped = &junitMessage{Message: e.Debug}
+				suite.Skipped++
+			case constant.OutputWarning:
+				testCase.SystemOut = constant.OutputWarning + ": " + e.Debug
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		elapsed += s.Elapsed
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = fmt.Sprintf("%.3f", elapsed)
+
+	data, err := xml.MarshalIndent(suites, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+)
+
+func mockSuite() *Suite {
+	environments := &model.Environments{
+		Source: &model.EnvType{Name: "DEV"},
+		Target: &model.EnvType{Name: "QA"},
+	}
+	suite := NewSuite("release.driver (Write)", "drivers/release.driver", constant.Write, environments)
+	suite.Add("view", "view_1.xml", model.Output{Code: constant.OutputSuccess, Statistics: &model.OutputStatistics{TotalNumberOfRecords: 1, UpdatedRecords: 1}}, time.Second)
+	suite.Add("lookup", "lookup_1.xml", model.Output{Code: constant.OutputError, Debug: "output statistics failure on 1 records out of 1"}, time.Second)
+	suite.Add("object", "object_1.xml", model.Output{Code: constant.OutputWarning, Debug: "attribute already exists"}, time.Second)
+	suite.Add("portlet", "portlet_1.xml", model.Output{Code: constant.OutputIgnored, Debug: "read ignored"}, 0)
+	suite.Finish()
+	return suite
+}
+
+func TestSuiteAddToCountResults(t *testing.T) {
+	suite := mockSuite()
+
+	if len(suite.Entries) != 4 {
+		t.Fatalf("Error adding entries to suite. Expected 4 entries received %d", len(suite.Entries))
+	}
+	if suite.Source != "DEV" || suite.Target != "QA" {
+		t.Errorf("Error creating suite. Invalid environments source = %s target = %s", suite.Source, suite.Target)
+	}
+	for _, code := range []string{constant.OutputSuccess, constant.OutputError, constant.OutputWarning, constant.OutputIgnored} {
+		if suite.Results[code] != 1 {
+			t.Errorf("Error counting suite results. Expected 1 %s received %d", code, suite.Results[code])
+		}
+	}
+}
+
+func TestReportResultsToMergeSuites(t *testing.T) {
+	r := &Report{}
+	r.Add(mockSuite(), nil, mockSuite())
+
+	if len(r.Suites) != 2 {
+		t.Fatalf("Error adding suites to report. Expected 2 suites received %d", len(r.Suites))
+	}
+	results := r.Results()
+	if results[constant.OutputError] != 2 || results[constant.OutputSuccess] != 2 {
+		t.Errorf("Error merging report results. Received %v", results)
+	}
+}
+
+func TestReportSaveToWriteJSONAndJUnit(t *testing.T) {
+	folder := "../" + constant.FolderReport
+	defer os.RemoveAll(folder)
+
+	r := &Report{}
+	r.Add(mockSuite())
+	path := folder + "test/report"
+	err := r.Save(path)
+	if err != nil {
+		t.Fatalf("Error saving report. Debug: %s", err.Error())
+	}
+
+	data, err := ioutil.ReadFile(path + ".json")
+	if err != nil {
+		t.Fatalf("Error saving report. JSON file not created. Debug: %s", err.Error())
+	}
+	saved := &Report{}
+	err = json.Unmarshal(data, saved)
+	if err != nil {
+		t.Fatalf("Error saving report. Invalid JSON. Debug: %s", err.Error())
+	}
+	if len(saved.Suites) != 1 || len(saved.Suites[0].Entries) != 4 {
+		t.Fatalf("Error saving report. Invalid JSON content")
+	}
+	if saved.Suites[0].Entries[0].Statistics == nil || saved.Suites[0].Entries[0].Statistics.UpdatedRecords != 1 {
+		t.Errorf("Error saving report. JSON without entry statistics")
+	}
+
+	data, err = ioutil.ReadFile(path + ".xml")
+	if err != nil {
+		t.Fatalf("Error saving report. JUnit file not created. Debug: %s", err.Error())
+	}
+	junit := string(data)
+	if !strings.Contains(junit, `<testsuites tests="4" failures="1" skipped="1"`) {
+		t.Errorf("Error saving report. Invalid JUnit totals")
+	}
+	if !strings.Contains(junit, `<failure message="output statistics failure on 1 records out of 1" type="error"></failure>`) {
+		t.Errorf("Error saving report. JUnit without failure for error entry")
+	}
+}
@@ -0,0 +1,204 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+//Prefixes of the credentials values that are not stored as plain text
+const (
+	EncryptedPrefix = "enc:"
+	EnvPrefix       = "env:"
+)
+
+//Sources of the master secret used to encrypt the credentials, checked in this order before asking the passphrase
+const (
+	KeyFileVariable    = "CAS_XOG_KEY_FILE"
+	DefaultKeyFile     = "xogEnv.key"
+	PassphraseVariable = "CAS_XOG_PASSPHRASE"
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+var (
+	mutex            sync.Mutex
+	master           []byte
+	passphrasePrompt func() (string, error)
+)
+
+//SetPassphrasePrompt defines how the master passphrase is asked when there is no key file or environment variable defined
+func SetPassphrasePrompt(prompt func() (string, error)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	passphrasePrompt = prompt
+}
+
+//SetMaster defines the master secret used to encrypt and decrypt the credentials. Use nil to load it again from its sources
+func SetMaster(m []byte) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	master = m
+}
+
+//Master returns the master secret from the key file defined in CAS_XOG_KEY_FILE, the key file xogEnv.key,
+//the variable CAS_XOG_PASSPHRASE or the passphrase prompt, in this order. The secret is loaded only once
+func Master() ([]byte, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if master != nil {
+		return master, nil
+	}
+
+	var err error
+	switch {
+	case os.Getenv(KeyFileVariable) != "":
+		master, err = ReadKeyFile(os.Getenv(KeyFileVariable))
+	case fileExists(DefaultKeyFile):
+		master, err = ReadKeyFile(DefaultKeyFile)
+	case os.Getenv(PassphraseVariable) != "":
+		master = []byte(os.Getenv(PassphraseVariable))
+	case passphrasePrompt != nil:
+		var passphrase string
+		passphrase, err = passphrasePrompt()
+		if err == nil && passphrase == "" {
+			err = errors.New("empty master passphrase")
+		}
+		master = []byte(passphrase)
+	default:
+		err = fmt.Errorf("no master passphrase or key file defined, use the variable %s or %s", PassphraseVariable, KeyFileVariable)
+	}
+	if err != nil {
+		master = nil
+		return nil, err
+	}
+	return master, nil
+}
+
+//ReadKeyFile returns the master secret stored in the key file
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file %s - %s", path, err.Error())
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return nil, fmt.Errorf("empty key file %s", path)
+	}
+	return []byte(key), nil
+}
+
+//GenerateKeyFile creates a key file with a random master secret, readable only by its owner
+func GenerateKeyFile(path string) error {
+	if fileExists(path) {
+		return fmt.Errorf("key file %s already exists", path)
+	}
+	key := make([]byte, keySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+}
+
+//IsEncrypted validates if the value was created by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+//Resolve returns the plain text of a credential. Values env:NAME are read from the environment variable NAME,
+//values enc:... are decrypted with the master secret and any other value is returned as is
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, EnvPrefix):
+		name := strings.TrimPrefix(value, EnvPrefix)
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s not defined", name)
+		}
+		return resolved, nil
+	case IsEncrypted(value):
+		m, err := Master()
+		if err != nil {
+			return "", err
+		}
+		return Decrypt(value, m)
+	}
+	return value, nil
+}
+
+//Encrypt returns the value encrypted with AES-GCM using a key derived from the master secret, prefixed with enc:
+func Encrypt(value string, master []byte) (string, error) {
+	salt := make([]byte, saltSize)
+	_, err := io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(master, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	data := append(salt, nonce...)
+	data = gcm.Seal(data, nonce, []byte(value), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+//Decrypt returns the plain text of a value created by Encrypt
+func Decrypt(value string, master []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(value), EncryptedPrefix))
+	if err != nil {
+		return "", errors.New("invalid encrypted value")
+	}
+	if len(data) < saltSize {
+		return "", errors.New("invalid encrypted value")
+	}
+	gcm, err := newGCM(master, data[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	data = data[saltSize:]
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("invalid master passphrase or key file")
+	}
+	return string(plain), nil
+}
+
+func newGCM(master, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(master, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
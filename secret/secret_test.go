@@ -0,0 +1,118 @@
+package secret
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	encrypted, err := Encrypt("p@ssw0rd", []byte("master"))
+	if err != nil {
+		t.Fatalf("Error encrypting value. Debug: %s", err.Error())
+	}
+	if !IsEncrypted(encrypted) || strings.Contains(encrypted, "p@ssw0rd") {
+		t.Fatalf("Error encrypting value, invalid result %s", encrypted)
+	}
+
+	other, _ := Encrypt("p@ssw0rd", []byte("master"))
+	if other == encrypted {
+		t.Errorf("Error encrypting value, expected a different result for each encryption")
+	}
+
+	plain, err := Decrypt(encrypted, []byte("master"))
+	if err != nil || plain != "p@ssw0rd" {
+		t.Errorf("Error decrypting value, expected p@ssw0rd received %s. Debug: %v", plain, err)
+	}
+}
+
+func TestDecryptInvalidMaster(t *testing.T) {
+	encrypted, _ := Encrypt("p@ssw0rd", []byte("master"))
+	_, err := Decrypt(encrypted, []byte("other master"))
+	if err == nil {
+		t.Errorf("Error decrypting value, not catching error with invalid master")
+	}
+	_, err = Decrypt(EncryptedPrefix+"invalid", []byte("master"))
+	if err == nil {
+		t.Errorf("Error decrypting value, not catching error with invalid value")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	defer SetMaster(nil)
+	os.Setenv("CAS_XOG_TEST_PASSWORD", "from environment")
+	defer os.Unsetenv("CAS_XOG_TEST_PASSWORD")
+
+	value, err := Resolve("env:CAS_XOG_TEST_PASSWORD")
+	if err != nil || value != "from environment" {
+		t.Errorf("Error resolving env value, expected 'from environment' received '%s'. Debug: %v", value, err)
+	}
+
+	_, err = Resolve("env:CAS_XOG_TEST_UNDEFINED")
+	if err == nil {
+		t.Errorf("Error resolving env value, not catching error with undefined variable")
+	}
+
+	value, _ = Resolve("plain")
+	if value != "plain" {
+		t.Errorf("Error resolving plain value, expected 'plain' received '%s'", value)
+	}
+
+	SetMaster([]byte("master"))
+	encrypted, _ := Encrypt("p@ssw0rd", []byte("master"))
+	value, err = Resolve(encrypted)
+	if err != nil || value != "p@ssw0rd" {
+		t.Errorf("Error resolving encrypted value, expected p@ssw0rd received %s. Debug: %v", value, err)
+	}
+}
+
+func TestMasterFromPassphrase(t *testing.T) {
+	defer SetMaster(nil)
+	defer SetPassphrasePrompt(nil)
+	SetMaster(nil)
+
+	calls := 0
+	SetPassphrasePrompt(func() (string, error) {
+		calls++
+		return "prompted", nil
+	})
+	os.Setenv(PassphraseVariable, "from variable")
+	m, err := Master()
+	os.Unsetenv(PassphraseVariable)
+	if err != nil || string(m) != "from variable" || calls > 0 {
+		t.Errorf("Error loading master, expected the passphrase from %s received %s", PassphraseVariable, string(m))
+	}
+
+	SetMaster(nil)
+	Master()
+	m, _ = Master()
+	if string(m) != "prompted" || calls != 1 {
+		t.Errorf("Error loading master, expected the prompted passphrase once received %s after %d prompts", string(m), calls)
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	defer SetMaster(nil)
+	path := "test.key"
+	defer os.Remove(path)
+
+	err := GenerateKeyFile(path)
+	if err != nil {
+		t.Fatalf("Error generating key file. Debug: %s", err.Error())
+	}
+	if GenerateKeyFile(path) == nil {
+		t.Errorf("Error generating key file, not catching error when the file already exists")
+	}
+
+	os.Setenv(KeyFileVariable, path)
+	defer os.Unsetenv(KeyFileVariable)
+	SetMaster(nil)
+	m, err := Master()
+	if err != nil || len(m) == 0 {
+		t.Fatalf("Error loading master from key file. Debug: %v", err)
+	}
+	key, _ := ReadKeyFile(path)
+	if string(key) != string(m) {
+		t.Errorf("Error loading master from key file, expected the key file content")
+	}
+}
@@ -6,21 +6,20 @@ import (
 	"strings"
 
 	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/migration"
 	"github.com/andreluzz/cas-xog/model"
-	"github.com/andreluzz/cas-xog/util"
 	"github.com/beevik/etree"
-	"github.com/tealeg/xlsx"
 )
 
 func specificDepartmentTransformations(xog *etree.Document, file *model.DriverFile) error {
 
-	if file.ExcelFile == constant.Undefined {
+	if file.ExcelFile == constant.Undefined || file.ExportToExcel {
 		return nil
 	}
 
-	xlFile, err := xlsx.OpenFile(util.ReplacePathSeparatorByOS(file.ExcelFile))
+	source, err := migration.OpenDataSource(file)
 	if err != nil {
-		return errors.New("OBS excel import - error opening excel. Debug: " + err.Error())
+		return errors.New("OBS excel import - " + err.Error())
 	}
 
 	excelStartRowIndex := 0
@@ -34,26 +33,26 @@ func specificDepartmentTransformations(xog *etree.Document, file *model.DriverFi
 
 	nodes := []Node{}
 
-	for rowIndex, row := range xlFile.Sheets[0].Rows {
+	for rowIndex, row := range source.Rows {
 		if rowIndex >= excelStartRowIndex {
 			node := Node{}
-			for index, cell := range row.Cells {
-				if cell.Value == constant.Undefined || index == len(row.Cells)-1 {
+			for index, value := range row {
+				if value == constant.Undefined || index == len(row)-1 {
 					i := strings.LastIndex(node.xpath, "/Department")
 					if i < 0 {
 						break
 					}
 					node.xpath = node.xpath[0:i]
-					if cell.Value != constant.Undefined {
-						node.name = cell.Value
+					if value != constant.Undefined {
+						node.name = value
 					}
 					break
 				}
 				if index%2 == 0 {
-					node.xpath += "/Department[@department_code='" + cell.Value + "']"
-					node.id = cell.Value
+					node.xpath += "/Department[@department_code='" + value + "']"
+					node.id = value
 				} else {
-					node.name = cell.Value
+					node.name = value
 				}
 			}
 			nodes = append(nodes, node)
@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/migration"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/beevik/etree"
+)
+
+func departmentUnits(xog *etree.Document) []string {
+	code := func(e *etree.Element) string { return e.SelectAttrValue("department_code", "") }
+	name := func(e *etree.Element) string { return e.SelectElement("Description").Text() }
+	return unitsTree(xog.FindElement("//Departments"), "Department", code, name, "")
+}
+
+func TestExportDepartmentsToExcelToImportAgain(t *testing.T) {
+	folder := packageMockFolder + "_export/"
+	defer os.RemoveAll(folder)
+
+	file := model.DriverFile{
+		Code:          "*",
+		Type:          constant.TypeDepartmentInstance,
+		ExportToExcel: true,
+		ExcelFile:     "departments.xlsx",
+		Sheet:         "Departments",
+		HeaderRow:     "2",
+	}
+	xog := etree.NewDocument()
+	xog.ReadFromFile(packageMockFolder + "department_full_xog.xml")
+	err := specificDepartmentTransformations(xog, &file)
+	if err != nil {
+		t.Fatalf("Error transforming department XOG file with exportToExcel. Debug: %s", err.Error())
+	}
+	err = migration.ExportInstancesToExcel(xog, &file, folder)
+	if err != nil {
+		t.Fatalf("Error exporting departments to excel. Debug: %s", err.Error())
+	}
+
+	imported := etree.NewDocument()
+	imported.ReadFromFile(packageMockFolder + "department_full_xog.xml")
+	importFile := model.DriverFile{ExcelFile: folder + file.ExcelFile, Sheet: "Departments", ExcelStartRow: "3", Entity: "B3"}
+	err = specificDepartmentTransformations(imported, &importFile)
+	if err != nil {
+		t.Fatalf("Error importing the exported departments excel. Debug: %s", err.Error())
+	}
+	expected := []string{
+		"/corp|Corporate",
+		"/corp|Corporate/it|Information Technology",
+		"/corp|Corporate/it|Information Technology/it_dev|Development",
+		"/corp|Corporate/it|Information Technology/it_ops|Operations",
+		"/corp|Corporate/hr|Human Resources",
+	}
+	if received := departmentUnits(imported); !reflect.DeepEqual(received, expected) {
+		t.Errorf("Error importing the exported departments excel. Expected departments %q and received %q", expected, received)
+	}
+}
@@ -1 +1,73 @@
-package transform
\ No newline at end of file
+package transform
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/migration"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/beevik/etree"
+)
+
+//unitsTree returns the path of each unit with the code and name of the unit and its parents
+func unitsTree(e *etree.Element, tag string, code, name func(*etree.Element) string, path string) []string {
+	units := []string{}
+	for _, u := range e.SelectElements(tag) {
+		unitPath := path + "/" + code(u) + "|" + name(u)
+		units = append(units, unitPath)
+		units = append(units, unitsTree(u, tag, code, name, unitPath)...)
+	}
+	return units
+}
+
+func obsUnits(xog *etree.Document) []string {
+	code := func(e *etree.Element) string { return e.SelectAttrValue("code", "") }
+	name := func(e *etree.Element) string { return e.SelectAttrValue("name", "") }
+	return unitsTree(xog.FindElement("//obs"), "unit", code, name, "")
+}
+
+func TestExportOBSToExcelToImportAgain(t *testing.T) {
+	folder := packageMockFolder + "_export/"
+	defer os.RemoveAll(folder)
+
+	file := model.DriverFile{
+		Code:          "strategic_plan",
+		Type:          constant.TypeOBSInstance,
+		ExportToExcel: true,
+		ExcelFile:     "obs.xlsx",
+	}
+	xog := etree.NewDocument()
+	xog.ReadFromFile(packageMockFolder + "obs_full_xog.xml")
+	err := Execute(xog, nil, &file)
+	if err != nil {
+		t.Fatalf("Error transforming OBS XOG file with exportToExcel. Debug: %s", err.Error())
+	}
+	err = migration.ExportInstancesToExcel(xog, &file, folder)
+	if err != nil {
+		t.Fatalf("Error exporting OBS to excel. Debug: %s", err.Error())
+	}
+
+	source, err := migration.OpenDataSource(&model.DriverFile{ExcelFile: folder + file.ExcelFile})
+	if err != nil {
+		t.Fatalf("Error exporting OBS to excel. Debug: %s", err.Error())
+	}
+	if !reflect.DeepEqual(source.Rows[0][:4], []string{"Level1 Code", "Level1 Name", "Level2 Code", "Level2 Name"}) {
+		t.Errorf("Error exporting OBS to excel. Debug: invalid header %q", source.Rows[0])
+	}
+	if !reflect.DeepEqual(source.Rows[2][:5], []string{"strategic_corp", "Corporate", "strategic_online_op_bu", "Online Operations", ""}) {
+		t.Errorf("Error exporting OBS to excel. Debug: invalid unit line %q", source.Rows[2])
+	}
+
+	imported := etree.NewDocument()
+	imported.ReadFromFile(packageMockFolder + "obs_full_xog.xml")
+	err = specificObsTransformations(imported, &model.DriverFile{ExcelFile: folder + file.ExcelFile, ExcelStartRow: "2"})
+	if err != nil {
+		t.Fatalf("Error importing the exported OBS excel. Debug: %s", err.Error())
+	}
+	expected := obsUnits(xog)
+	if received := obsUnits(imported); len(expected) == 0 || !reflect.DeepEqual(received, expected) {
+		t.Errorf("Error importing the exported OBS excel. Expected units %q and received %q", expected, received)
+	}
+}
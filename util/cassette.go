@@ -0,0 +1,267 @@
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Modes of a cassette
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+var cassetteRedactions = []struct {
+	regexp      *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`<xog:SessionID>[^<]*</xog:SessionID>`), "<xog:SessionID/>"},
+	{regexp.MustCompile(`<obj:SessionID>[^<]*</obj:SessionID>`), "<obj:SessionID/>"},
+	{regexp.MustCompile(`<obj:Username>[^<]*</obj:Username>`), "<obj:Username/>"},
+	{regexp.MustCompile(`<obj:Password>[^<]*</obj:Password>`), "<obj:Password/>"},
+}
+
+//cassetteResponseRedactions replaces the session and token of the login responses by a value that still logs in when replayed
+var cassetteResponseRedactions = []struct {
+	regexp      *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(<SessionID[^>]*>)[^<]*(</SessionID>)`), "${1}" + cassetteRedacted + "${2}"},
+	{regexp.MustCompile(`("authToken"\s*:\s*")[^"]*(")`), "${1}" + cassetteRedacted + "${2}"},
+}
+
+//cassetteRedacted is the value saved instead of the sessions and tokens returned by the environments
+const cassetteRedacted = "cassette-redacted"
+
+var cassetteFileRegexp = regexp.MustCompile(`^(soap|rest|login)_([0-9a-f]+)_(\d+)\.json$`)
+
+//activeCassette records or replays the requests of SoapCall, RestCall and APIPostLogin when defined
+var activeCassette *Cassette
+
+//Cassette saves each request and response to the environments in a folder, one json file per interaction, and serves them back without network access.
+//Requests are identified by kind, endpoint and content, without session ids and credentials, and the sessions and tokens of the login responses are
+//not saved. When the same request is executed many times the responses are returned in the order they were recorded
+type Cassette struct {
+	Folder   string
+	Mode     string
+	mutex    sync.Mutex
+	calls    map[string]int
+	recorded map[string]int
+}
+
+type interaction struct {
+	Kind        string            `json:"kind"`
+	Endpoint    string            `json:"endpoint"`
+	Method      string            `json:"method,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	Request     string            `json:"request,omitempty"`
+	Status      int               `json:"status,omitempty"`
+	Response    string            `json:"response"`
+	Error       string            `json:"error,omitempty"`
+	ErrorStatus int               `json:"errorStatus,omitempty"`
+}
+
+//NewCassette creates a cassette in the folder. In record mode the interactions of a previous recording are removed
+func NewCassette(folder, mode string) (*Cassette, error) {
+	c := &Cassette{Folder: strings.TrimSuffix(folder, "/") + "/", Mode: mode, calls: map[string]int{}, recorded: map[string]int{}}
+	switch mode {
+	case CassetteRecord:
+		err := ValidateFolder(c.Folder)
+		if err != nil {
+			return nil, err
+		}
+	case CassetteReplay:
+		if _, err := os.Stat(c.Folder); err != nil {
+			return nil, errors.New("cassette folder " + folder + " not found")
+		}
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %s", mode)
+	}
+
+	files, err := ioutil.ReadDir(c.Folder)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		match := cassetteFileRegexp.FindStringSubmatch(f.Name())
+		if match == nil {
+			continue
+		}
+		if mode == CassetteRecord {
+			os.Remove(c.Folder + f.Name())
+			continue
+		}
+		key := match[1] + "_" + match[2]
+		var n int
+		fmt.Sscanf(match[3], "%d", &n)
+		if n > c.recorded[key] {
+			c.recorded[key] = n
+		}
+	}
+	return c, nil
+}
+
+//SetCassette defines the cassette used to record or replay the requests. Use nil to call the environments directly
+func SetCassette(c *Cassette) {
+	activeCassette = c
+}
+
+//Total returns the number of interactions recorded or replayed
+func (c *Cassette) Total() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	total := 0
+	for _, n := range c.calls {
+		total += n
+	}
+	return total
+}
+
+func (c *Cassette) soap(request, endpoint, proxy string) (string, error) {
+	i := interaction{Kind: "soap", Endpoint: endpoint, Request: redact(request)}
+	err := c.play(&i, func() {
+		response, err := soapCall(request, endpoint, proxy)
+		i.Response = response
+		setInteractionError(&i, err)
+	})
+	if err != nil {
+		return "", err
+	}
+	if err = interactionError(&i); err != nil {
+		return "", err
+	}
+	return i.Response, nil
+}
+
+func (c *Cassette) rest(jsonString []byte, config APIConfig, params map[string]string) ([]byte, int, error) {
+	i := interaction{Kind: "rest", Endpoint: config.Endpoint, Method: config.Method, Params: params, Request: string(jsonString)}
+	err := c.play(&i, func() {
+		response, status, err := restCall(jsonString, config, params)
+		i.Response = string(response)
+		i.Status = status
+		setInteractionError(&i, err)
+	})
+	if err != nil {
+		return nil, -1, err
+	}
+	if err = interactionError(&i); err != nil {
+		return nil, i.Status, err
+	}
+	return []byte(i.Response), i.Status, nil
+}
+
+func (c *Cassette) login(endpoint, username, password, proxy, cookie string) ([]byte, error) {
+	i := interaction{Kind: "login", Endpoint: endpoint}
+	err := c.play(&i, func() {
+		response, err := apiPostLogin(endpoint, username, password, proxy, cookie)
+		i.Response = string(response)
+		setInteractionError(&i, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = interactionError(&i); err != nil {
+		return nil, err
+	}
+	return []byte(i.Response), nil
+}
+
+//play executes the call and saves the interaction when recording, or loads the recorded interaction when replaying
+func (c *Cassette) play(i *interaction, call func()) error {
+	key := i.key()
+	c.mutex.Lock()
+	c.calls[key]++
+	n := c.calls[key]
+	c.mutex.Unlock()
+
+	if c.Mode == CassetteReplay {
+		c.mutex.Lock()
+		recorded := c.recorded[key]
+		c.mutex.Unlock()
+		if recorded == 0 {
+			return fmt.Errorf("%s request to %s not recorded in cassette %s", i.Kind, i.Endpoint, c.Folder)
+		}
+		if n > recorded {
+			n = recorded
+		}
+		data, err := ioutil.ReadFile(c.path(key, n))
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, i)
+	}
+
+	call()
+	saved := *i
+	saved.Response = redactResponse(saved.Response)
+	data, err := json.MarshalIndent(saved, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path(key, n), JSONAvoidEscapeText(data), 0600)
+}
+
+func (c *Cassette) path(key string, n int) string {
+	return filepath.Join(c.Folder, fmt.Sprintf("%s_%d.json", key, n))
+}
+
+//key identifies the request by its kind and content, ignoring the values that change between executions
+func (i *interaction) key() string {
+	content := []string{i.Kind, i.Endpoint, i.Method, i.Request}
+	names := []string{}
+	for name := range i.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		content = append(content, name+"="+i.Params[name])
+	}
+	hash := sha1.Sum([]byte(strings.Join(content, "\n")))
+	return i.Kind + "_" + hex.EncodeToString(hash[:8])
+}
+
+func redact(request string) string {
+	for _, r := range cassetteRedactions {
+		request = r.regexp.ReplaceAllString(request, r.replacement)
+	}
+	return request
+}
+
+func redactResponse(response string) string {
+	for _, r := range cassetteResponseRedactions {
+		response = r.regexp.ReplaceAllString(response, r.replacement)
+	}
+	return response
+}
+
+func setInteractionError(i *interaction, err error) {
+	if err == nil {
+		return
+	}
+	i.Error = err.Error()
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		i.ErrorStatus = statusErr.StatusCode
+		i.Response = statusErr.Body
+	}
+}
+
+func interactionError(i *interaction) error {
+	if i.ErrorStatus != 0 {
+		return &StatusError{StatusCode: i.ErrorStatus, Body: i.Response}
+	}
+	if i.Error != "" {
+		return errors.New(i.Error)
+	}
+	return nil
+}
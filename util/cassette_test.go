@@ -0,0 +1,109 @@
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "cassette")
+	defer os.RemoveAll(folder)
+	defer SetCassette(nil)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		if strings.HasSuffix(r.URL.Path, "/auth/login") {
+			w.Write([]byte(`{"authToken":"token 1"}`))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/soap/login") {
+			w.Write([]byte(`<SessionID xmlns="http://www.niku.com/xog/Object">session 1</SessionID>`))
+			return
+		}
+		w.Write([]byte("<response" + strconv.Itoa(calls) + "/>"))
+	}))
+
+	c, err := NewCassette(folder, CassetteRecord)
+	if err != nil {
+		t.Fatalf("Error creating cassette. Debug: %s", err.Error())
+	}
+	SetCassette(c)
+
+	request := "<xog:SessionID>%s</xog:SessionID><obj:Password>secret</obj:Password><read/>"
+	first, _ := SoapCall(strings.Replace(request, "%s", "session 1", 1), server.URL, "")
+	second, _ := SoapCall(strings.Replace(request, "%s", "session 2", 1), server.URL, "")
+	soapLogin, _ := SoapCall("<obj:Login/>", server.URL+"/soap/login", "")
+	restLogin, _ := APIPostLogin(server.URL+"/auth/login", "admin", "secret", "", "")
+	config := APIConfig{Endpoint: server.URL + "/missing", Method: http.MethodGet, Token: "token"}
+	_, status, _ := RestCall(nil, config, map[string]string{"limit": "10"})
+	server.Close()
+
+	if !strings.Contains(soapLogin, "session 1") || !strings.Contains(string(restLogin), "token 1") {
+		t.Fatalf("Error recording cassette. Login responses changed while recording: %s | %s", soapLogin, restLogin)
+	}
+
+	if first != "<response1/>" || second != "<response2/>" || status != http.StatusNotFound {
+		t.Fatalf("Error recording cassette. Invalid responses: %s | %s | %d", first, second, status)
+	}
+	files, _ := ioutil.ReadDir(folder)
+	if len(files) != 5 {
+		t.Fatalf("Error recording cassette. Expected 5 interactions saved received %d", len(files))
+	}
+	for _, f := range files {
+		data, _ := ioutil.ReadFile(folder + "/" + f.Name())
+		if strings.Contains(string(data), "secret") || strings.Contains(string(data), "session 1") || strings.Contains(string(data), "token 1") {
+			t.Errorf("Error recording cassette. Credentials or session saved in %s", f.Name())
+		}
+	}
+
+	c, err = NewCassette(folder, CassetteReplay)
+	if err != nil {
+		t.Fatalf("Error loading cassette. Debug: %s", err.Error())
+	}
+	SetCassette(c)
+
+	first, err = SoapCall(strings.Replace(request, "%s", "session 3", 1), server.URL, "")
+	second, _ = SoapCall(strings.Replace(request, "%s", "session 4", 1), server.URL, "")
+	if err != nil || first != "<response1/>" || second != "<response2/>" {
+		t.Errorf("Error replaying cassette. Responses not returned in the recorded order: %s | %s | %v", first, second, err)
+	}
+	soapLogin, _ = SoapCall("<obj:Login/>", server.URL+"/soap/login", "")
+	if soapLogin != `<SessionID xmlns="http://www.niku.com/xog/Object">`+cassetteRedacted+`</SessionID>` {
+		t.Errorf("Error replaying cassette. Invalid soap login response: %s", soapLogin)
+	}
+	restLogin, _ = APIPostLogin(server.URL+"/auth/login", "admin", "secret", "", "")
+	if string(restLogin) != `{"authToken":"`+cassetteRedacted+`"}` {
+		t.Errorf("Error replaying cassette. Invalid rest login response: %s", restLogin)
+	}
+	_, status, _ = RestCall(nil, config, map[string]string{"limit": "10"})
+	if status != http.StatusNotFound {
+		t.Errorf("Error replaying cassette. Expected rest status 404 received %d", status)
+	}
+	_, err = SoapCall("<write/>", server.URL, "")
+	if err == nil {
+		t.Errorf("Error replaying cassette. Request not recorded returned no error")
+	}
+	if c.Total() != 6 {
+		t.Errorf("Error replaying cassette. Expected 6 interactions received %d", c.Total())
+	}
+}
+
+func TestNewCassetteInvalid(t *testing.T) {
+	_, err := NewCassette("missing_cassette_folder", CassetteReplay)
+	if err == nil {
+		t.Errorf("Error loading cassette. Missing folder accepted")
+	}
+	_, err = NewCassette(os.TempDir(), "play")
+	if err == nil {
+		t.Errorf("Error creating cassette. Invalid mode accepted")
+	}
+}
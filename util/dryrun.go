@@ -0,0 +1,83 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+//DryRunSoapResponse is the xog output returned by the dry-run soap function in place of the environment response
+const DryRunSoapResponse = `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body><XOGOutput><Status state="SUCCESS"/></XOGOutput></soapenv:Body></soapenv:Envelope>`
+
+//DryRunSoap returns a soap function that saves the request envelope to path instead of calling the environment
+func DryRunSoap(path string) Soap {
+	return func(request, endpoint, proxy string) (string, error) {
+		err := ValidateFolder(filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		err = ioutil.WriteFile(path, []byte(request), 0644)
+		if err != nil {
+			return "", fmt.Errorf("error saving dry-run request. Debug: %s", err.Error())
+		}
+		return DryRunSoapResponse, nil
+	}
+}
+
+//DryRunRequest defines a rest request registered by the dry-run
+type DryRunRequest struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Params map[string]string `json:"params,omitempty"`
+	Body   interface{}       `json:"body,omitempty"`
+}
+
+//DryRunRest registers the sequence of rest requests that would be sent to the environment
+type DryRunRest struct {
+	Requests []DryRunRequest
+}
+
+//Call implements the Rest interface registering the request and returning a placeholder response
+func (d *DryRunRest) Call(jsonString []byte, config APIConfig, params map[string]string) ([]byte, int, error) {
+	request := DryRunRequest{
+		Method: config.Method,
+		URL:    config.Endpoint,
+		Params: params,
+	}
+	if jsonString != nil {
+		var body interface{}
+		if err := json.Unmarshal(jsonString, &body); err == nil {
+			request.Body = body
+		} else {
+			request.Body = string(jsonString)
+		}
+	}
+	d.Requests = append(d.Requests, request)
+
+	self := strings.Replace(config.Endpoint, `"`, `\"`, -1)
+	response := fmt.Sprintf(`{"_internalId": 0, "_self": "%s", "_results": [{"_internalId": 0, "_self": "%s"}]}`, self, self)
+	return []byte(response), 200, nil
+}
+
+//Save writes the registered requests in the order they were made as a json array
+func (d *DryRunRest) Save(path string) error {
+	err := ValidateFolder(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	requests := d.Requests
+	if requests == nil {
+		requests = []DryRunRequest{}
+	}
+	data, err := json.MarshalIndent(requests, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, JSONAvoidEscapeText(data), 0644)
+	if err != nil {
+		return fmt.Errorf("error saving dry-run requests. Debug: %s", err.Error())
+	}
+	return nil
+}
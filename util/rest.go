@@ -6,8 +6,6 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/url"
-	"time"
 )
 
 //Rest defines a rest interface to simplify the unit tests
@@ -28,6 +26,13 @@ func RestCall(jsonString []byte, config APIConfig, params map[string]string) ([]
 	if config.Token == "" {
 		return nil, -1, fmt.Errorf("invalid token")
 	}
+	if activeCassette != nil {
+		return activeCassette.rest(jsonString, config, params)
+	}
+	return restCall(jsonString, config, params)
+}
+
+func restCall(jsonString []byte, config APIConfig, params map[string]string) ([]byte, int, error) {
 	var body io.Reader
 	if jsonString != nil {
 		body = bytes.NewBuffer(jsonString)
@@ -59,21 +64,12 @@ func RestCall(jsonString []byte, config APIConfig, params map[string]string) ([]
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{
-		Timeout: time.Second * 60,
-	}
-
-	if config.Proxy != "" {
-		proxyURL, err := url.Parse(config.Proxy)
-		if err != nil {
-			return nil, -1, err
-		}
-		client.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
+	t, err := getTransport(config.Endpoint, config.Proxy)
+	if err != nil {
+		return nil, -1, err
 	}
 
-	resp, err := client.Do(req)
+	resp, err := t.rest.Do(req)
 
 	if err != nil {
 		return nil, -1, err
@@ -85,6 +81,13 @@ func RestCall(jsonString []byte, config APIConfig, params map[string]string) ([]
 
 //APIPostLogin send a post to get the auth token
 func APIPostLogin(endpoint, username, password, proxy, cookie string) ([]byte, error) {
+	if activeCassette != nil {
+		return activeCassette.login(endpoint, username, password, proxy, cookie)
+	}
+	return apiPostLogin(endpoint, username, password, proxy, cookie)
+}
+
+func apiPostLogin(endpoint, username, password, proxy, cookie string) ([]byte, error) {
 	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -94,21 +97,12 @@ func APIPostLogin(endpoint, username, password, proxy, cookie string) ([]byte, e
 		req.Header.Add("Cookie", cookie)
 	}
 
-	client := &http.Client{
-		Timeout: time.Second * 60,
-	}
-
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return nil, err
-		}
-		client.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
+	t, err := getTransport(endpoint, proxy)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := client.Do(req)
+	resp, err := t.rest.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -2,39 +2,101 @@ package util
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
-	"net/url"
-	"time"
+	"reflect"
 )
 
 //Soap defines a soap interface to simplify the unit tests
 type Soap func(request, endpoint, proxy string) (string, error)
 
+//SoapStream defines a soap interface that writes the response to w instead of returning it, used for large responses
+type SoapStream func(request, endpoint, proxy string, w io.Writer) error
+
+//StatusError defines a response with an unexpected http status code
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code: %d | response: %s", e.StatusCode, e.Body)
+}
+
 //SoapCall executes a soap call to the defined environment executing the xog xml
 func SoapCall(request, endpoint, proxy string) (string, error) {
-	client := &http.Client{
-		Timeout: time.Second * 600,
+	if activeCassette != nil {
+		return activeCassette.soap(request, endpoint, proxy)
 	}
+	return soapCall(request, endpoint, proxy)
+}
 
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return "", err
-		}
-		client.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
+func soapCall(request, endpoint, proxy string) (string, error) {
+	t, err := getTransport(endpoint, proxy)
+	if err != nil {
+		return "", err
 	}
 
-	resp, err := client.Post(endpoint+"/niku/xog", "text/xml; charset=utf-8", bytes.NewBufferString(request))
+	resp, err := t.soap.Post(endpoint+"/niku/xog", "text/xml; charset=utf-8", bytes.NewBufferString(request))
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", err
 	}
+	//soap faults are returned with status 500 and are validated by the caller
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+		return "", &StatusError{StatusCode: resp.StatusCode, Body: BytesToString(body)}
+	}
 
 	return BytesToString(body), nil
 }
+
+//SoapStreamOf returns the stream of the soap function. SoapCall is streamed copying the response as it is received, the other functions,
+//like the mocks of the unit tests, have their response written to w
+func SoapStreamOf(soapFunc Soap) SoapStream {
+	if reflect.ValueOf(soapFunc).Pointer() == reflect.ValueOf(SoapCall).Pointer() {
+		return SoapCallToWriter
+	}
+	return func(request, endpoint, proxy string, w io.Writer) error {
+		response, err := soapFunc(request, endpoint, proxy)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, response)
+		return err
+	}
+}
+
+//SoapCallToWriter executes a soap call to the defined environment copying the response to w as it is received
+func SoapCallToWriter(request, endpoint, proxy string, w io.Writer) error {
+	if activeCassette != nil {
+		response, err := activeCassette.soap(request, endpoint, proxy)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, response)
+		return err
+	}
+
+	t, err := getTransport(endpoint, proxy)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.soap.Post(endpoint+"/niku/xog", "text/xml; charset=utf-8", bytes.NewBufferString(request))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: BytesToString(body)}
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
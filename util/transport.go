@@ -0,0 +1,181 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Default timeouts of the requests when the environment does not define them
+const (
+	DefaultSoapTimeout = 600 * time.Second
+	DefaultRestTimeout = 60 * time.Second
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+//TransportConfig defines the connection settings of an environment
+type TransportConfig struct {
+	Proxy              string
+	ProxyUsername      string
+	ProxyPassword      string
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	MinTLSVersion      string
+	InsecureSkipVerify bool
+	SoapTimeout        time.Duration
+	RestTimeout        time.Duration
+}
+
+type transport struct {
+	soap *http.Client
+	rest *http.Client
+}
+
+var (
+	transportMutex sync.Mutex
+	//transports registered by endpoint, used by the requests whose URL starts with the endpoint
+	transports = map[string]*transport{}
+	//transports shared by the endpoints without settings, one for each proxy
+	defaultTransports = map[string]*transport{}
+)
+
+//ValidTLSVersion validates if the version is one of 1.0, 1.1, 1.2 or 1.3
+func ValidTLSVersion(version string) bool {
+	_, ok := tlsVersions[version]
+	return ok
+}
+
+//RegisterTransport creates the transport used by all the requests to the endpoint. The connections are reused across the calls
+func RegisterTransport(endpoint string, config TransportConfig) error {
+	t, err := newTransport(config)
+	if err != nil {
+		return err
+	}
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+	if previous, ok := transports[endpoint]; ok {
+		previous.soap.CloseIdleConnections()
+	}
+	transports[endpoint] = t
+	return nil
+}
+
+func newTransport(config TransportConfig) (*transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		if config.ProxyUsername != "" {
+			proxyURL.User = url.UserPassword(config.ProxyUsername, config.ProxyPassword)
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	base.TLSClientConfig = tlsConfig
+
+	soapTimeout := config.SoapTimeout
+	if soapTimeout <= 0 {
+		soapTimeout = DefaultSoapTimeout
+	}
+	restTimeout := config.RestTimeout
+	if restTimeout <= 0 {
+		restTimeout = DefaultRestTimeout
+	}
+	return &transport{
+		soap: &http.Client{Transport: base, Timeout: soapTimeout},
+		rest: &http.Client{Transport: base, Timeout: restTimeout},
+	}, nil
+}
+
+func newTLSConfig(config TransportConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+
+	if config.MinTLSVersion != "" {
+		version, ok := tlsVersions[config.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid minimum tls version %s, use 1.0, 1.1, 1.2 or 1.3", config.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if config.CAFile != "" {
+		data, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ca file %s - %s", config.CAFile, err.Error())
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificates found in ca file " + config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, errors.New("client certificate requires both certFile and keyFile")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate %s - %s", config.CertFile, err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+//getTransport returns the transport registered with the longest endpoint that prefixes the url, or the shared transport of the proxy
+func getTransport(requestURL, proxy string) (*transport, error) {
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+
+	var found *transport
+	length := -1
+	for endpoint, t := range transports {
+		if matchEndpoint(requestURL, endpoint) && len(endpoint) > length {
+			found = t
+			length = len(endpoint)
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+
+	if t, ok := defaultTransports[proxy]; ok {
+		return t, nil
+	}
+	t, err := newTransport(TransportConfig{Proxy: proxy})
+	if err != nil {
+		return nil, err
+	}
+	defaultTransports[proxy] = t
+	return t, nil
+}
+
+func matchEndpoint(requestURL, endpoint string) bool {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	return requestURL == endpoint || strings.HasPrefix(requestURL, endpoint+"/")
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTLSTestServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sleep") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("<response/>"))
+	}))
+}
+
+func TestSoapCallWithCAFile(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+	defer delete(transports, server.URL)
+
+	_, err := SoapCall("<request/>", server.URL, "")
+	if err == nil {
+		t.Fatalf("Error executing soap call. Certificate signed by unknown authority accepted")
+	}
+
+	caFile, _ := ioutil.TempFile("", "ca*.pem")
+	defer os.Remove(caFile.Name())
+	pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	caFile.Close()
+
+	err = RegisterTransport(server.URL, TransportConfig{CAFile: caFile.Name(), MinTLSVersion: "1.2"})
+	if err != nil {
+		t.Fatalf("Error registering transport. Debug: %s", err.Error())
+	}
+	response, err := SoapCall("<request/>", server.URL, "")
+	if err != nil || response != "<response/>" {
+		t.Errorf("Error executing soap call with ca file. Debug: %v", err)
+	}
+}
+
+func TestRestCallInsecureAndTimeout(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+	defer delete(transports, server.URL)
+
+	err := RegisterTransport(server.URL, TransportConfig{InsecureSkipVerify: true, RestTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Error registering transport. Debug: %s", err.Error())
+	}
+
+	config := APIConfig{Endpoint: server.URL + "/ppm/rest/v1/projects", Method: http.MethodGet, Token: "token"}
+	_, status, err := RestCall(nil, config, nil)
+	if err != nil || status != http.StatusOK {
+		t.Errorf("Error executing rest call with insecure transport. Debug: %v", err)
+	}
+
+	_, _, err = RestCall(nil, config, map[string]string{"sleep": "true"})
+	if err == nil {
+		t.Errorf("Error executing rest call. Request did not respect the environment timeout")
+	}
+}
+
+func TestRegisterTransportInvalidSettings(t *testing.T) {
+	invalid := []TransportConfig{
+		{MinTLSVersion: "1.4"},
+		{CAFile: "missing.pem"},
+		{CertFile: "client.pem"},
+		{Proxy: "://proxy"},
+	}
+	for _, c := range invalid {
+		if err := RegisterTransport("https://invalid", c); err == nil {
+			t.Errorf("Error registering transport. Invalid settings accepted: %+v", c)
+		}
+	}
+	if _, ok := transports["https://invalid"]; ok {
+		t.Errorf("Error registering transport. Invalid transport registered")
+	}
+}
@@ -64,6 +64,10 @@ func GetActionLabel(action string) string {
 		return "Write"
 	case constant.Migrate:
 		return "Create"
+	case constant.Compare:
+		return "Compare"
+	case constant.Rollback:
+		return "Rollback"
 	}
 	return ""
 }
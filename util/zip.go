@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -64,3 +65,37 @@ func Unzip(src, destinationFolder string) ([]string, error) {
 	}
 	return filenames, nil
 }
+
+//Zip creates a zip with the files, using the key as the name inside the zip and the value as its content
+func Zip(files map[string][]byte, destination string) error {
+	err := os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	zipFile, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := zip.NewWriter(zipFile)
+	for _, name := range names {
+		f, err := w.Create(filepath.ToSlash(name))
+		if err != nil {
+			w.Close()
+			return err
+		}
+		_, err = f.Write(files[name])
+		if err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,461 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/migration"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//Issue describes a problem found in a driver file and where it is
+type Issue struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.Path, i.Line, i.Column, i.Message)
+}
+
+//schema defines the attributes and sub tags accepted by a driver tag. A nil schema accepts any content
+type schema struct {
+	attrs    map[string]bool
+	children map[string]*schema
+}
+
+//node is a driver tag with its position in the file
+type node struct {
+	tag      string
+	attrs    map[string]string
+	line     int
+	column   int
+	children []*node
+}
+
+func (n *node) attr(name string) string {
+	return n.attrs[name]
+}
+
+func (n *node) has(name string) bool {
+	_, ok := n.attrs[name]
+	return ok
+}
+
+func (n *node) childrenByTag(tag string) []*node {
+	list := []*node{}
+	for _, c := range n.children {
+		if c.tag == tag {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+//driverEntry is a driver file already checked, used to find duplicates and dependencies
+type driverEntry struct {
+	key  string
+	path string
+	file string
+	line int
+}
+
+type driverLinter struct {
+	rootSchema *schema
+	fileSchema *schema
+	types      map[string]string
+	issues     []Issue
+	entries    []driverEntry
+}
+
+//Driver checks every entry of the driver against the tags known by the model.DriverTypesPattern, the required attributes of each type,
+//the actions of the tags element and section, duplicated paths and the excel and template files referenced. Included drivers are checked too
+func Driver(path string) ([]Issue, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	l := newDriverLinter()
+	l.lint(path, []string{}, constant.Undefined, 0, 0)
+	return l.issues, nil
+}
+
+func newDriverLinter() *driverLinter {
+	patternType := reflect.TypeOf(model.DriverTypesPattern{})
+	fileType := reflect.TypeOf(model.DriverFile{})
+	l := &driverLinter{
+		rootSchema: &schema{attrs: map[string]bool{}, children: map[string]*schema{}},
+		fileSchema: schemaOf(fileType),
+		types:      map[string]string{},
+	}
+	for i := 0; i < patternType.NumField(); i++ {
+		field := patternType.Field(i)
+		name, isAttr := xmlName(field)
+		if name == constant.Undefined {
+			continue
+		}
+		if isAttr {
+			l.rootSchema.attrs[name] = true
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem() == fileType {
+			l.rootSchema.children[name] = l.fileSchema
+			l.types[name] = field.Name
+		}
+	}
+	l.rootSchema.children["include"] = &schema{attrs: map[string]bool{"path": true}}
+	l.rootSchema.children["barrier"] = &schema{}
+	l.rootSchema.children["file"] = nil
+	l.rootSchema.children["vars"] = &schema{children: map[string]*schema{"var": {attrs: map[string]bool{"name": true, "value": true}}}}
+	return l
+}
+
+//schemaOf builds the schema from the xml tags of the struct fields
+func schemaOf(t reflect.Type) *schema {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	s := &schema{attrs: map[string]bool{}, children: map[string]*schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, isAttr := xmlName(field)
+		if name == constant.Undefined {
+			continue
+		}
+		if isAttr {
+			s.attrs[name] = true
+		} else {
+			s.children[name] = schemaOf(field.Type)
+		}
+	}
+	return s
+}
+
+func xmlName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("xml")
+	if !ok || tag == "-" {
+		return constant.Undefined, false
+	}
+	parts := strings.Split(tag, ",")
+	isAttr := len(parts) > 1 && parts[1] == "attr"
+	if strings.Contains(parts[0], ">") {
+		return constant.Undefined, false
+	}
+	return parts[0], isAttr
+}
+
+func (l *driverLinter) add(path string, line, column int, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{Path: path, Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+//lint checks the driver and its includes. The parent is the driver that includes this one, undefined for the driver being validated
+func (l *driverLinter) lint(path string, chain []string, parent string, line, column int) {
+	absPath, _ := filepath.Abs(path)
+	for _, p := range chain {
+		if p == absPath {
+			l.add(parent, line, column, "include cycle with driver %s", path)
+			return
+		}
+	}
+	chain = append(chain, absPath)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		l.add(path, 0, 0, "error loading driver file - %s", err.Error())
+		return
+	}
+
+	doc, parseErr := parseNodes(data)
+	if parseErr != nil {
+		l.add(path, parseErr.line, parseErr.column, "%s", parseErr.message)
+		return
+	}
+	if doc == nil {
+		l.add(path, 1, 1, "driver file is empty")
+		return
+	}
+	if doc.tag != "xogdriver" {
+		l.add(path, doc.line, doc.column, "tag <%s> is incorrect, expected <xogdriver>", doc.tag)
+		return
+	}
+	l.checkUnknown(path, doc, l.rootSchema)
+
+	if parent == constant.Undefined {
+		v, err := strconv.ParseFloat(doc.attr("version"), 64)
+		if err != nil || v < constant.Version {
+			l.add(path, doc.line, doc.column, "invalid driver version, expected version %.1f or greater", constant.Version)
+		}
+	}
+
+	for _, e := range doc.children {
+		switch e.tag {
+		case "include":
+			includePath := e.attr("path")
+			if includePath == constant.Undefined {
+				l.add(path, e.line, e.column, "tag <include> without attribute path")
+				continue
+			}
+			if !filepath.IsAbs(includePath) {
+				includePath = filepath.Join(filepath.Dir(path), includePath)
+			}
+			if _, err := os.Stat(includePath); err != nil {
+				l.add(path, e.line, e.column, "included driver %s not found", includePath)
+				continue
+			}
+			l.lint(includePath, chain, path, e.line, e.column)
+		case "file":
+			l.add(path, e.line, e.column, "tag <file> is no longer supported")
+		default:
+			if fileType, ok := l.types[e.tag]; ok {
+				l.checkFile(path, e, fileType)
+			}
+		}
+	}
+}
+
+//checkUnknown reports the tags and attributes that are not defined in the schema
+func (l *driverLinter) checkUnknown(path string, n *node, s *schema) {
+	if s == nil {
+		return
+	}
+	names := []string{}
+	for name := range n.attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !s.attrs[name] && !strings.HasPrefix(name, "xmlns") {
+			l.add(path, n.line, n.column, "unknown attribute %s on tag <%s>", name, n.tag)
+		}
+	}
+	for _, c := range n.children {
+		child, ok := s.children[c.tag]
+		if !ok {
+			l.add(path, c.line, c.column, "unknown tag <%s> inside <%s>", c.tag, n.tag)
+			continue
+		}
+		l.checkUnknown(path, c, child)
+	}
+}
+
+func (l *driverLinter) checkFile(path string, e *node, fileType string) {
+	required := func(attrs ...string) {
+		for _, a := range attrs {
+			if e.attr(a) == constant.Undefined {
+				l.add(path, e.line, e.column, "no attribute %s defined on tag <%s>", a, e.tag)
+			}
+		}
+	}
+
+	required("path")
+	switch fileType {
+	case constant.TypeMigration:
+		required("template", "excel")
+	case "API" + constant.APITypeBlueprint:
+		required("id")
+	case "API" + constant.APITypeTeam:
+		if e.attr("excel") == constant.Undefined {
+			required("code")
+		}
+	case "API" + constant.APITypeTask:
+	default:
+		if e.attr("code") == constant.Undefined && len(e.childrenByTag("filter")) == 0 {
+			l.add(path, e.line, e.column, "no attribute code defined on tag <%s>", e.tag)
+		}
+		if fileType == constant.TypeView || fileType == constant.TypeCustomObjectInstance {
+			required("objectCode")
+		}
+	}
+
+	if excel := e.attr("excel"); excel != constant.Undefined && e.attr("exportToExcel") != "true" {
+		l.checkFileExists(path, e, "excel", excel)
+		source := model.DriverFile{ExcelFile: excel, Delimiter: e.attr("delimiter"), Quote: e.attr("quote"), Encoding: e.attr("encoding")}
+		if err := migration.ValidateDataSource(&source); err != nil {
+			l.add(path, e.line, e.column, "%s on tag <%s>", err.Error(), e.tag)
+		}
+	}
+	if template := e.attr("template"); template != constant.Undefined {
+		l.checkFileExists(path, e, "template", template)
+	}
+
+	for _, s := range e.childrenByTag("section") {
+		switch s.attr("action") {
+		case constant.ActionReplace, constant.ActionUpdate, constant.ActionRemove, constant.ActionInsert:
+		default:
+			l.add(path, s.line, s.column, "invalid action attribute (%s) on tag <section>, expected %s, %s, %s or %s", s.attr("action"), constant.ActionReplace, constant.ActionUpdate, constant.ActionRemove, constant.ActionInsert)
+		}
+	}
+
+	for _, sh := range e.childrenByTag("sheet") {
+		if err := migration.ValidateExcelSheet(model.ExcelSheet{Name: sh.attr("name"), XPath: sh.attr("xpath"), CustomAttributes: sh.attr("customAttributes") == "true"}); err != nil {
+			l.add(path, sh.line, sh.column, "%s", err.Error())
+		}
+	}
+
+	for _, el := range e.childrenByTag("element") {
+		l.checkElement(path, el, fileType)
+	}
+
+	for _, m := range e.childrenByTag("match") {
+		switch {
+		case m.has("header") && m.has("col"):
+			l.add(path, m.line, m.column, "attributes col and header cannot be used together on tag <match>")
+		case m.has("header"), !m.has("col") && e.attr("exportToExcel") == "true":
+		case !m.has("col"):
+			l.add(path, m.line, m.column, "no attribute col or header defined on tag <match>")
+		default:
+			if _, err := strconv.Atoi(m.attr("col")); err != nil {
+				l.add(path, m.line, m.column, "invalid attribute col (%s) on tag <match>, expected a number", m.attr("col"))
+			}
+		}
+	}
+
+	key := e.tag + "|" + e.attr("path")
+	for _, entry := range l.entries {
+		if entry.key == key && e.attr("path") != constant.Undefined {
+			l.add(path, e.line, e.column, "duplicate path %s on tag <%s>, already defined at %s:%d", e.attr("path"), e.tag, entry.file, entry.line)
+			break
+		}
+	}
+	if dependsOn := e.attr("dependsOn"); dependsOn != constant.Undefined && !l.defined(dependsOn) {
+		l.add(path, e.line, e.column, "attribute dependsOn references %s that is not defined before it", dependsOn)
+	}
+	l.entries = append(l.entries, driverEntry{key: key, path: e.attr("path"), file: path, line: e.line})
+}
+
+func (l *driverLinter) checkElement(path string, el *node, fileType string) {
+	action := el.attr("action")
+	if el.attr("xpath") != constant.Undefined {
+		switch action {
+		case constant.ActionInsert, constant.ActionRemove, constant.ActionRemoveAllButNot:
+		default:
+			l.add(path, el.line, el.column, "invalid action attribute (%s) on tag <element>, expected %s, %s or %s", action, constant.ActionInsert, constant.ActionRemove, constant.ActionRemoveAllButNot)
+		}
+		return
+	}
+
+	elementType := el.attr("type")
+	if el.attr("code") == constant.Undefined {
+		l.add(path, el.line, el.column, "no attribute code defined on tag <element>")
+	}
+	switch fileType {
+	case constant.TypeObject:
+		switch elementType {
+		case constant.ElementTypeAttribute, constant.ElementTypeAction, constant.ElementTypeLink:
+		default:
+			l.add(path, el.line, el.column, "invalid type attribute (%s) on tag <element>, expected %s, %s or %s", elementType, constant.ElementTypeAttribute, constant.ElementTypeAction, constant.ElementTypeLink)
+		}
+	case constant.TypeView:
+		switch elementType {
+		case constant.ElementTypeAction, constant.ElementTypeActionGroup:
+		default:
+			l.add(path, el.line, el.column, "invalid type attribute (%s) on tag <element>, expected %s or %s", elementType, constant.ElementTypeAction, constant.ElementTypeActionGroup)
+		}
+		switch action {
+		case constant.ActionInsert, constant.ActionRemove:
+		default:
+			l.add(path, el.line, el.column, "invalid action attribute (%s) on tag <element>, expected %s or %s", action, constant.ActionInsert, constant.ActionRemove)
+		}
+	default:
+		l.add(path, el.line, el.column, "no attribute xpath defined on tag <element>")
+	}
+}
+
+func (l *driverLinter) checkFileExists(path string, e *node, attr, file string) {
+	if strings.Contains(file, "${") {
+		return
+	}
+	if _, err := os.Stat(util.ReplacePathSeparatorByOS(file)); err != nil {
+		l.add(path, e.line, e.column, "%s file %s not found", attr, file)
+	}
+}
+
+func (l *driverLinter) defined(path string) bool {
+	for _, entry := range l.entries {
+		if entry.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+type parseError struct {
+	line    int
+	column  int
+	message string
+}
+
+//parseNodes reads the xml tags keeping their line and column
+func parseNodes(data []byte) (*node, *parseError) {
+	lineStarts := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	position := func(offset int64) (int, int) {
+		line := 1
+		for line < len(lineStarts) && int64(lineStarts[line]) <= offset {
+			line++
+		}
+		return line, int(offset) - lineStarts[line-1] + 1
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *node
+	stack := []*node{}
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, column := position(decoder.InputOffset())
+			if syntaxErr, ok := err.(*xml.SyntaxError); ok {
+				line = syntaxErr.Line
+				return nil, &parseError{line: line, column: column, message: "invalid xml - " + syntaxErr.Msg}
+			}
+			return nil, &parseError{line: line, column: column, message: "invalid xml - " + err.Error()}
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, column := position(offset)
+			n := &node{tag: t.Name.Local, attrs: map[string]string{}, line: line, column: column}
+			for _, a := range t.Attr {
+				name := a.Name.Local
+				if a.Name.Space == "xmlns" {
+					name = "xmlns:" + name
+				}
+				n.attrs[name] = a.Value
+			}
+			if len(stack) == 0 {
+				if root == nil {
+					root = n
+				}
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return root, nil
+}
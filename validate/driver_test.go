@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDriverWithoutIssues(t *testing.T) {
+	issues, err := Driver(packageMockFolder + "drivers/valid.driver")
+
+	if err != nil {
+		t.Fatalf("Error validating driver. Debug: %s", err.Error())
+	}
+	if len(issues) != 0 {
+		t.Errorf("Error validating driver. Expected no issues and received %v", issues)
+	}
+}
+
+func TestDriverWithIssues(t *testing.T) {
+	path := packageMockFolder + "drivers/invalid.driver"
+	issues, err := Driver(path)
+
+	if err != nil {
+		t.Fatalf("Error validating driver. Debug: %s", err.Error())
+	}
+
+	expected := []string{
+		path + ":4:5: unknown tag <objetc> inside <xogdriver>",
+		path + ":5:5: unknown attribute sourcePartiton on tag <view>",
+		path + ":5:5: no attribute objectCode defined on tag <view>",
+		path + ":6:9: invalid action attribute (move) on tag <section>",
+		path + ":7:9: invalid action attribute (replace) on tag <element>",
+		path + ":9:5: duplicate path lookup_1.xml on tag <lookup>",
+		path + ":10:5: no attribute code defined on tag <process>",
+		path + ":11:5: excel file missing.xlsx not found",
+		path + ":12:9: invalid attribute col (A) on tag <match>",
+		path + ":14:5: attribute dependsOn references page.xml",
+		path + ":15:5: invalid attribute delimiter (;;)",
+		path + ":17:9: tag sheet Attributes requires the attribute xpath or customAttributes",
+	}
+
+	if len(issues) != len(expected) {
+		t.Fatalf("Error validating driver. Expected %d issues and received %d: %v", len(expected), len(issues), issues)
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(issues[i].String(), e) {
+			t.Errorf("Error validating driver. Expected issue %s and received %s", e, issues[i].String())
+		}
+	}
+}
+
+func TestDriverWithInvalidXML(t *testing.T) {
+	issues, err := Driver(packageMockFolder + "drivers/invalidXML.driver")
+
+	if err != nil {
+		t.Fatalf("Error validating driver. Debug: %s", err.Error())
+	}
+	if len(issues) != 1 || issues[0].Line != 5 || !strings.Contains(issues[0].Message, "invalid xml") {
+		t.Errorf("Error validating driver. Expected invalid xml issue on line 5 and received %v", issues)
+	}
+}
+
+func TestDriverInvalidPath(t *testing.T) {
+	_, err := Driver(packageMockFolder + "drivers/notFound.driver")
+
+	if err == nil {
+		t.Errorf("Error validating driver. Not catching error with invalid file path")
+	}
+}
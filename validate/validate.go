@@ -5,6 +5,7 @@ import (
 	"github.com/andreluzz/cas-xog/constant"
 	"github.com/andreluzz/cas-xog/model"
 	"github.com/beevik/etree"
+	"strconv"
 )
 
 //Check verify the xog response output looking for errors and warnings
@@ -31,6 +32,11 @@ func Check(xog *etree.Document) (model.Output, error) {
 		return errorOutput, errors.New(errorOutput.Debug)
 	}
 
+	statisticsElement := output.FindElement("Statistics")
+	statistics := getStatistics(statisticsElement)
+	errorOutput.Statistics = statistics
+	warningOutput.Statistics = statistics
+
 	errorInformationElement := output.FindElement("//ErrorInformation")
 
 	if errorInformationElement != nil {
@@ -50,8 +56,6 @@ func Check(xog *etree.Document) (model.Output, error) {
 		}
 	}
 
-	statisticsElement := output.FindElement("Statistics")
-
 	if statisticsElement != nil {
 		statTotalNumberOfRecords := statisticsElement.SelectAttrValue("totalNumberOfRecords", "0")
 		if statTotalNumberOfRecords == "0" {
@@ -72,5 +76,21 @@ func Check(xog *etree.Document) (model.Output, error) {
 		debug = "| Elapsed time: " + elapsedTime
 	}
 
-	return model.Output{Code: constant.OutputSuccess, Debug: debug}, nil
+	return model.Output{Code: constant.OutputSuccess, Debug: debug, Statistics: statistics}, nil
+}
+
+func getStatistics(statisticsElement *etree.Element) *model.OutputStatistics {
+	if statisticsElement == nil {
+		return nil
+	}
+	attrToInt := func(key string) int {
+		value, _ := strconv.Atoi(statisticsElement.SelectAttrValue(key, "0"))
+		return value
+	}
+	return &model.OutputStatistics{
+		TotalNumberOfRecords: attrToInt("totalNumberOfRecords"),
+		InsertedRecords:      attrToInt("insertedRecords"),
+		UpdatedRecords:       attrToInt("updatedRecords"),
+		FailureRecords:       attrToInt("failureRecords"),
+	}
 }
@@ -135,3 +135,28 @@ func TestCheckToValidateInvalidXml(t *testing.T) {
 		t.Errorf("Expected the error 'invalid xog' but instead received '%s'.", err.Error())
 	}
 }
+
+func TestCheckToReturnStatistics(t *testing.T) {
+	xog := etree.NewDocument()
+	xog.ReadFromFile(packageMockFolder + "mockSuccess.xml")
+
+	output, _ := Check(xog)
+
+	if output.Statistics == nil {
+		t.Fatalf("Expected output statistics but received nil")
+	}
+	if output.Statistics.TotalNumberOfRecords != 1 || output.Statistics.UpdatedRecords != 2 || output.Statistics.FailureRecords != 0 {
+		t.Errorf("Invalid output statistics received %+v", *output.Statistics)
+	}
+}
+
+func TestCheckToReturnStatisticsOnFailure(t *testing.T) {
+	xog := etree.NewDocument()
+	xog.ReadFromFile(packageMockFolder + "mockOneFailureResult.xml")
+
+	output, _ := Check(xog)
+
+	if output.Statistics == nil || output.Statistics.FailureRecords != 1 {
+		t.Errorf("Expected output statistics with one failure record")
+	}
+}
@@ -0,0 +1,120 @@
+package view
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/report"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/xog"
+)
+
+//renderRollback displays the available backups and writes the one chosen back to the environment it was saved from
+func renderRollback() {
+	backups, err := xog.GetBackupsList(constant.FolderBackup)
+	if err != nil {
+		log.Warn("\n[CAS-XOG][yellow[WARNING]] - %s\n", err.Error())
+		return
+	}
+
+	log.Info("\n")
+	log.Info("Available backups:\n")
+	for i, b := range backups {
+		log.Info("%d - %s\n", i+1, b)
+	}
+	log.Info("Choose backup to rollback [1]: ")
+	input := "1"
+	scanLine(&input)
+
+	index, err := strconv.Atoi(input)
+	if err != nil || index-1 < 0 || index > len(backups) {
+		log.Error("\n[CAS-XOG][red[ERROR]] - Invalid backup!\n")
+		return
+	}
+
+	suite, err := rollback(backups[index-1], constant.Undefined)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return
+	}
+	if suite != nil {
+		saveReport(constant.Undefined, suite)
+	}
+}
+
+//rollback loads the backup and writes its files to the target environment. If targetName is empty the environment backed up is used
+func rollback(folder, targetName string) (*report.Suite, error) {
+	total, envName, created, err := xog.LoadBackup(folder)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("\n[CAS-XOG][blue[Loaded backup]]: %s | Environment: %s | Total files: [green[%d]]\n", folder, envName, total)
+	for _, c := range created {
+		log.Warn("\n[CAS-XOG][yellow[Warning]]: %s did not exist before the write and cannot be removed by the rollback, remove it manually", c)
+	}
+
+	if targetName == constant.Undefined {
+		targetName = envName
+	}
+	if !strings.EqualFold(targetName, envName) {
+		log.Warn("\n[CAS-XOG][yellow[Warning]]: Trying to rollback a backup of %s into a different environment: %s!", envName, targetName)
+		if !confirm("Do you want to continue anyway?") {
+			return nil, nil
+		}
+	}
+	if !confirm("Write the backup to environment " + targetName + "?") {
+		return nil, nil
+	}
+
+	err = selectEnvironmentsByName(constant.Write, constant.Undefined, targetName, environments)
+	if err != nil {
+		return nil, err
+	}
+	defer environments.Logout(util.SoapCall)
+
+	return ProcessDriverFiles(xog.GetLoadedDriver(), constant.Rollback, environments), nil
+}
+
+func rollbackCommand(args []string, version string) int {
+	flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	folder := flags.String("backup", constant.Undefined, "backup folder to write back, default is the most recent backup")
+	targetName := flags.String("target", constant.Undefined, "name of the environment used for writing, default is the environment backed up")
+	flags.BoolVar(&unattended.yes, "yes", false, "answer yes to all confirmations")
+	flags.StringVar(&unattended.username, "username", constant.Undefined, "username for environments without credentials in xogEnv.xml")
+	flags.StringVar(&unattended.password, "password", constant.Undefined, "password for environments without credentials in xogEnv.xml")
+	reportPath := flags.String("report", constant.Undefined, "path without extension to save the JSON and JUnit XML reports")
+	addCassetteFlags(flags)
+	addLogFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return constant.ExitCodeUsage
+	}
+
+	err := initialize(version)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+
+	if *folder == constant.Undefined {
+		backups, err := xog.GetBackupsList(constant.FolderBackup)
+		if err != nil {
+			log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+			return constant.ExitCodeUsage
+		}
+		*folder = backups[0]
+	}
+
+	suite, err := rollback(*folder, *targetName)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+	if suite == nil {
+		return constant.ExitCodeSuccess
+	}
+	return exitCodeFromSuites(*reportPath, suite)
+}
@@ -0,0 +1,48 @@
+package view
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+//cassetteSettings stores the folders of the flags record and replay
+var cassetteSettings struct {
+	record string
+	replay string
+}
+
+func addCassetteFlags(flags *flag.FlagSet) {
+	flags.StringVar(&cassetteSettings.record, "record", constant.Undefined, "folder to save every request and response to the environments")
+	flags.StringVar(&cassetteSettings.replay, "replay", constant.Undefined, "folder with the requests recorded with --record, served back without calling the environments")
+}
+
+//initCassette defines the cassette used by the soap and rest calls when recording or replaying
+func initCassette() error {
+	util.SetCassette(nil)
+	folder, mode := cassetteSettings.record, util.CassetteRecord
+	if cassetteSettings.replay != constant.Undefined {
+		if folder != constant.Undefined {
+			return errors.New("the flags --record and --replay cannot be used together")
+		}
+		folder, mode = cassetteSettings.replay, util.CassetteReplay
+	}
+	if folder == constant.Undefined {
+		return nil
+	}
+
+	cassette, err := util.NewCassette(folder, mode)
+	if err != nil {
+		return err
+	}
+	util.SetCassette(cassette)
+	if mode == util.CassetteRecord {
+		log.Info("\n[CAS-XOG][yellow[Recording]]: requests and responses will be saved to %s\n", cassette.Folder)
+	} else {
+		log.Info("\n[CAS-XOG][yellow[Replaying]]: responses will be loaded from %s without calling the environments\n", cassette.Folder)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package view
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/report"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/xog"
+)
+
+//unattended stores the answers used in place of the interactive prompts when running from the command line
+var unattended struct {
+	enabled  bool
+	yes      bool
+	username string
+	password string
+}
+
+//concurrency overrides the number of driver files processed in parallel defined in the driver
+var concurrency int
+
+//resume skips the driver files completed in the previous run of the driver, registered in its checkpoint journal
+var resume bool
+
+//dryRun saves the requests that would be sent to the target environment instead of executing them
+var dryRun bool
+
+//backupWrites saves the target state before each write, even if the driver does not define the attribute backup
+var backupWrites bool
+
+type command func(args []string, version string) int
+
+var commands = map[string]command{
+	"run":         runCommand,
+	"validate":    validateCommand,
+	"discover":    discoverCommand,
+	"package":     packageCommand,
+	"rollback":    rollbackCommand,
+	"credentials": credentialsCommand,
+}
+
+//Command executes a command line subcommand without user interaction. Returns false if args do not define a valid subcommand
+func Command(args []string, version string) (int, bool) {
+	if len(args) == 0 {
+		return constant.ExitCodeSuccess, false
+	}
+	cmd, ok := commands[strings.ToLower(args[0])]
+	if !ok {
+		return constant.ExitCodeSuccess, false
+	}
+	unattended.enabled = true
+	return cmd(args[1:], version), true
+}
+
+type keyValueFlag map[string]string
+
+func (k keyValueFlag) String() string {
+	pairs := []string{}
+	for key, value := range k {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (k keyValueFlag) Set(value string) error {
+	index := strings.Index(value, "=")
+	if index <= 0 {
+		return fmt.Errorf("invalid value %s, expected key=value", value)
+	}
+	k[value[:index]] = value[index+1:]
+	return nil
+}
+
+func runCommand(args []string, version string) int {
+	definitions := keyValueFlag{}
+	variables := keyValueFlag{}
+
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	driverPath := flags.String("driver", constant.Undefined, "path to the driver file")
+	action := flags.String("action", constant.Undefined, "action to execute (r = Read, w = Write, m = Create Migration, c = Compare, p = Install Package)")
+	sourceName := flags.String("source", constant.Undefined, "name of the environment used for reading")
+	targetName := flags.String("target", constant.Undefined, "name of the environment used for writing")
+	packageName := flags.String("package", constant.Undefined, "name of the package to install")
+	packageVersion := flags.String("package-version", constant.Undefined, "name of the package version to install")
+	flags.Var(definitions, "define", "package definition answer as description=value, can be repeated")
+	flags.Var(variables, "var", "driver variable value as name=value, overrides the driver and environment values, can be repeated")
+	flags.BoolVar(&unattended.yes, "yes", false, "answer yes to all confirmations")
+	flags.StringVar(&unattended.username, "username", constant.Undefined, "username for environments without credentials in xogEnv.xml")
+	flags.StringVar(&unattended.password, "password", constant.Undefined, "password for environments without credentials in xogEnv.xml")
+	flags.BoolVar(&backupWrites, "backup", false, "save the target state to the _backup folder before each write of action w")
+	flags.BoolVar(&dryRun, "dry-run", false, "save the requests of actions w and p to the _dryrun folder without calling the target environment")
+	flags.IntVar(&concurrency, "concurrency", 0, "number of driver files processed in parallel, overrides the driver concurrency attribute")
+	flags.BoolVar(&resume, "resume", false, "skip the driver files already completed by the previous interrupted run of the driver")
+	reportPath := flags.String("report", constant.Undefined, "path without extension to save the JSON and JUnit XML reports")
+	comparePath := flags.String("compare-report", constant.Undefined, "path without extension to save the JSON and HTML reports of action c")
+	addCassetteFlags(flags)
+	addLogFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return constant.ExitCodeUsage
+	}
+
+	*action = strings.ToLower(*action)
+	err := validateRunFlags(*action, *driverPath, *sourceName, *targetName, *packageName, dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: %s\n", err.Error())
+		flags.Usage()
+		return constant.ExitCodeUsage
+	}
+
+	err = initialize(version)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+	xog.SetVariableOverrides(variables)
+
+	if *action == constant.Package {
+		return runPackageCommand(*packageName, *packageVersion, *targetName, *reportPath, definitions)
+	}
+
+	total, err := xog.LoadDriver(*driverPath)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+	log.Info("\n[CAS-XOG][blue[Loaded driver file]]: %s | Total files: [green[%d]]\n", *driverPath, total)
+
+	err = selectEnvironmentsByName(*action, *sourceName, *targetName, environments)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+	defer environments.Logout(util.SoapCall)
+
+	err = resolveVariables(*action, environments)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+
+	driver := xog.GetLoadedDriver()
+	if *action == constant.Compare {
+		return exitCodeFromSuites(*reportPath, CompareDriverFiles(driver, environments, *comparePath))
+	}
+
+	suites := []*report.Suite{ProcessDriverFiles(driver, *action, environments)}
+	if *action == constant.Read && driver.AutomaticWrite {
+		log.Warn("\n[CAS-XOG][yellow[Warning]]: This driver is configured to write automatically!")
+		if confirm("Do you want to proceed?") {
+			suites = append(suites, ProcessDriverFiles(driver, constant.Write, environments))
+		}
+	}
+
+	return exitCodeFromSuites(*reportPath, suites...)
+}
+
+func validateCommand(args []string, version string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	driverPath := flags.String("driver", constant.Undefined, "path to the driver file")
+
+	if err := flags.Parse(args); err != nil {
+		return constant.ExitCodeUsage
+	}
+	if *driverPath == constant.Undefined {
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: command validate requires the flag --driver\n")
+		flags.Usage()
+		return constant.ExitCodeUsage
+	}
+
+	if !validateDriver(*driverPath) {
+		return constant.ExitCodeError
+	}
+	return constant.ExitCodeSuccess
+}
+
+func runPackageCommand(packageName, packageVersion, targetName, reportPath string, definitions map[string]string) int {
+	xog.LoadPackages(constant.FolderPackage, "packages/")
+	selectedPackage, selectedVersion, err := selectPackageByName(packageName, packageVersion, definitions)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[PACKAGE]]: %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+
+	err = selectEnvironmentsByName(constant.Package, constant.Undefined, targetName, environments)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+
+	suites, err := InstallPackage(environments, selectedPackage, selectedVersion)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[PACKAGE]]: %s\n", err.Error())
+		return constant.ExitCodeError
+	}
+	return exitCodeFromSuites(reportPath, suites...)
+}
+
+func validateRunFlags(action, driverPath, sourceName, targetName, packageName string, dryRun bool) error {
+	if dryRun && action != constant.Write && action != constant.Package {
+		return fmt.Errorf("flag --dry-run is only available for actions %s and %s", constant.Write, constant.Package)
+	}
+
+	switch action {
+	case constant.Read, constant.Compare:
+		if sourceName == constant.Undefined || targetName == constant.Undefined {
+			return fmt.Errorf("action %s requires the flags --source and --target", action)
+		}
+	case constant.Write:
+		if targetName == constant.Undefined {
+			return fmt.Errorf("action %s requires the flag --target", action)
+		}
+	case constant.Migrate:
+	case constant.Package:
+		if packageName == constant.Undefined || targetName == constant.Undefined {
+			return fmt.Errorf("action %s requires the flags --package and --target", action)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid action: '%s'", action)
+	}
+
+	if driverPath == constant.Undefined {
+		return fmt.Errorf("action %s requires the flag --driver", action)
+	}
+	return nil
+}
+
+func exitCodeFromSuites(reportPath string, suites ...*report.Suite) int {
+	saveReport(reportPath, suites...)
+
+	r := &report.Report{}
+	r.Add(suites...)
+	results := r.Results()
+	if results[constant.OutputError] > 0 {
+		return constant.ExitCodeError
+	}
+	if results[constant.OutputWarning] > 0 {
+		return constant.ExitCodeWarning
+	}
+	return constant.ExitCodeSuccess
+}
+
+func confirm(question string) bool {
+	if unattended.enabled {
+		log.Info("\n[CAS-XOG]%s (y = Yes, n = No): %t\n", question, unattended.yes)
+		return unattended.yes
+	}
+	log.Info("\n[CAS-XOG]%s (y = Yes, n = No) [n]: ", question)
+	input := "n"
+	scanLine(&input)
+	return input == "y"
+}
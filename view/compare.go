@@ -0,0 +1,81 @@
+package view
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/andreluzz/cas-xog/compare"
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/report"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/xog"
+)
+
+//CompareDriverFiles compares the driver files between the source and the target environments, saves the compare report as JSON and HTML and returns the report suite.
+//Artifacts with differences are registered in the suite as warnings
+func CompareDriverFiles(driver *model.Driver, environments *model.Environments, comparePath string) *report.Suite {
+	suite := report.NewSuite(driver.FilePath+" ("+util.GetActionLabel(constant.Compare)+")", driver.FilePath, constant.Compare, environments)
+	compareReport := compare.NewReport(driver.FilePath, environments.Source.Name, environments.Target.Name)
+
+	log.Info("\n------------------------------------------------------------------")
+	log.Info("\n[blue[Initiated at]]: %s", suite.Started.Format("Mon _2 Jan 2006 - 15:04:05"))
+	log.Info("\nComparing driver: %s | %s x %s", driver.FilePath, environments.Source.Name, environments.Target.Name)
+	log.Info("\n------------------------------------------------------------------\n")
+
+	os.RemoveAll(constant.FolderCompare + environments.Source.Name)
+	os.RemoveAll(constant.FolderCompare + environments.Target.Name)
+
+	total := len(driver.Files)
+	typePadLength := driver.MaxTypeNameLen()
+	for i, f := range driver.Files {
+		formattedType := util.RightPad(f.GetXMLType(), " ", typePadLength)
+		log.Info("\n[CAS-XOG][blue[Comparing]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path)
+		fileStart := time.Now()
+		artifact := xog.CompareDriverFile(&f, environments, util.SoapCall)
+		compareReport.Add(artifact)
+
+		output := model.Output{Code: constant.OutputSuccess, Debug: artifact.Debug}
+		switch artifact.Status {
+		case compare.StatusDifferent:
+			summary := artifact.Summary()
+			output.Code = constant.OutputWarning
+			output.Debug = fmt.Sprintf("added: %d | removed: %d | changed: %d | moved: %d", summary[compare.KindAdded], summary[compare.KindRemoved], summary[compare.KindChanged], summary[compare.KindMoved])
+		case compare.StatusError:
+			output.Code = constant.OutputError
+		case compare.StatusIgnored:
+			output.Code = constant.OutputIgnored
+		}
+		suite.Add(f.GetXMLType(), f.Path, output, time.Since(fileStart))
+
+		color := "green"
+		switch artifact.Status {
+		case compare.StatusDifferent, compare.StatusIgnored:
+			color = "yellow"
+		case compare.StatusError:
+			color = "red"
+		}
+		log.Info("\r[CAS-XOG][%s[Compare %-9s]] %03d/%03d | [blue[%s]] | file: %s %s", color, artifact.Status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
+	}
+
+	suite.Finish()
+	if comparePath == constant.Undefined {
+		comparePath = compare.DefaultPath()
+	}
+	err := compareReport.Save(comparePath)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+	} else {
+		log.Info("\n\n[CAS-XOG][blue[Compare report saved]]: %s.json | %s.html", comparePath, comparePath)
+	}
+
+	results := compareReport.Results
+	log.Info("\n\n-----------------------------------------------------------------------------")
+	log.Info("\nStats: total = %d | equal = %d | different = %d | failure = %d | ignored = %d", total, results[compare.StatusEqual], results[compare.StatusDifferent], results[compare.StatusError], results[compare.StatusIgnored])
+	log.Info("\n[blue[Concluded in]]: %.3f seconds", suite.Elapsed)
+	log.Info("\n-----------------------------------------------------------------------------\n")
+
+	return suite
+}
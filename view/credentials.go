@@ -0,0 +1,235 @@
+package view
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/secret"
+	"github.com/beevik/etree"
+	"github.com/howeyc/gopass"
+)
+
+//credentialTags maps the fields accepted by the command credentials to the tags of the environments file
+var credentialTags = map[string]string{"username": "username", "password": "password", "token": "api"}
+
+//askPassphrase is used to ask the master passphrase when an encrypted credential is used
+func askPassphrase() (string, error) {
+	if unattended.enabled {
+		return "", fmt.Errorf("no master passphrase or key file defined, use the variable %s or %s", secret.PassphraseVariable, secret.KeyFileVariable)
+	}
+	return readMasked("\n[CAS-XOG][yellow[Master passphrase needed]] - Enter the passphrase of the encrypted credentials: ")
+}
+
+func readMasked(label string) (string, error) {
+	log.Info(label)
+	value, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func credentialsCommand(args []string, version string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: command credentials requires a subcommand: encrypt, rotate or keygen\n")
+		return constant.ExitCodeUsage
+	}
+
+	var err error
+	switch strings.ToLower(args[0]) {
+	case "encrypt":
+		err = encryptCredential(args[1:])
+	case "rotate":
+		err = rotateCredentials(args[1:])
+	case "keygen":
+		err = generateKeyFile(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: invalid credentials subcommand: '%s'\n", args[0])
+		return constant.ExitCodeUsage
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: %s\n", err.Error())
+		return constant.ExitCodeError
+	}
+	return constant.ExitCodeSuccess
+}
+
+//encryptCredential encrypts a value and saves it in the environment, or prints it if no environment is defined
+func encryptCredential(args []string) error {
+	flags := flag.NewFlagSet("credentials encrypt", flag.ContinueOnError)
+	file := flags.String("file", "xogEnv.xml", "path to the environments file")
+	envName := flags.String("env", constant.Undefined, "name of the environment to save the encrypted value, if empty the value is printed")
+	field := flags.String("field", "password", "credential to encrypt: username, password or token")
+	value := flags.String("value", constant.Undefined, "value to encrypt, asked without echo if empty")
+	keyFile := flags.String("key-file", constant.Undefined, "key file used as master secret instead of the passphrase")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tag, ok := credentialTags[strings.ToLower(*field)]
+	if !ok {
+		return fmt.Errorf("invalid field %s, expected username, password or token", *field)
+	}
+
+	master, err := loadMaster(*keyFile, "Master passphrase: ")
+	if err != nil {
+		return err
+	}
+
+	if *value == constant.Undefined {
+		*value, err = readMasked("Value of " + *field + ": ")
+		if err != nil {
+			return err
+		}
+	}
+	encrypted, err := secret.Encrypt(*value, master)
+	if err != nil {
+		return err
+	}
+
+	if *envName == constant.Undefined {
+		fmt.Println(encrypted)
+		return nil
+	}
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromFile(*file)
+	if err != nil {
+		return fmt.Errorf("error reading environments file %s - %s", *file, err.Error())
+	}
+	env := findEnvironmentElement(doc, *envName)
+	if env == nil {
+		return fmt.Errorf("environment %s not found in %s", *envName, *file)
+	}
+	e := env.SelectElement(tag)
+	if e == nil {
+		e = env.CreateElement(tag)
+	}
+	e.SetText(encrypted)
+
+	err = doc.WriteToFile(*file)
+	if err != nil {
+		return err
+	}
+	log.Info("[CAS-XOG][green[Encrypted]] %s of environment %s saved in %s\n", *field, *envName, *file)
+	return nil
+}
+
+//rotateCredentials encrypts again all the encrypted credentials of the environments file with a new master secret
+func rotateCredentials(args []string) error {
+	flags := flag.NewFlagSet("credentials rotate", flag.ContinueOnError)
+	file := flags.String("file", "xogEnv.xml", "path to the environments file")
+	keyFile := flags.String("key-file", constant.Undefined, "current key file, if the credentials were encrypted with a key file")
+	newKeyFile := flags.String("new-key-file", constant.Undefined, "new key file used as master secret, created if it does not exist")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	doc := etree.NewDocument()
+	err := doc.ReadFromFile(*file)
+	if err != nil {
+		return fmt.Errorf("error reading environments file %s - %s", *file, err.Error())
+	}
+
+	current, err := loadMaster(*keyFile, "Current master passphrase: ")
+	if err != nil {
+		return err
+	}
+
+	var next []byte
+	if *newKeyFile != constant.Undefined {
+		if _, statErr := os.Stat(*newKeyFile); os.IsNotExist(statErr) {
+			err = secret.GenerateKeyFile(*newKeyFile)
+			if err != nil {
+				return err
+			}
+		}
+		next, err = secret.ReadKeyFile(*newKeyFile)
+	} else {
+		next, err = askNewPassphrase()
+	}
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, env := range doc.FindElements("//env") {
+		for _, tag := range credentialTags {
+			e := env.SelectElement(tag)
+			if e == nil || !secret.IsEncrypted(strings.TrimSpace(e.Text())) {
+				continue
+			}
+			plain, err := secret.Decrypt(e.Text(), current)
+			if err != nil {
+				return fmt.Errorf("environment %s %s - %s", env.SelectAttrValue("name", constant.Undefined), tag, err.Error())
+			}
+			encrypted, err := secret.Encrypt(plain, next)
+			if err != nil {
+				return err
+			}
+			e.SetText(encrypted)
+			total++
+		}
+	}
+
+	err = doc.WriteToFile(*file)
+	if err != nil {
+		return err
+	}
+	log.Info("[CAS-XOG][green[Rotated]] %d encrypted credentials saved in %s\n", total, *file)
+	return nil
+}
+
+func generateKeyFile(args []string) error {
+	flags := flag.NewFlagSet("credentials keygen", flag.ContinueOnError)
+	keyFile := flags.String("key-file", secret.DefaultKeyFile, "path of the key file to create")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	err := secret.GenerateKeyFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	log.Info("[CAS-XOG][green[Key file created]]: %s | Keep it out of version control\n", *keyFile)
+	return nil
+}
+
+//loadMaster returns the master secret from the key file, if defined, or from the default sources asking the passphrase if needed
+func loadMaster(keyFile, label string) ([]byte, error) {
+	if keyFile != constant.Undefined {
+		return secret.ReadKeyFile(keyFile)
+	}
+	secret.SetPassphrasePrompt(func() (string, error) {
+		return readMasked(label)
+	})
+	return secret.Master()
+}
+
+func askNewPassphrase() ([]byte, error) {
+	passphrase, err := readMasked("New master passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	confirmation, err := readMasked("Confirm the new master passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == constant.Undefined || passphrase != confirmation {
+		return nil, errors.New("the new master passphrase is empty or does not match the confirmation")
+	}
+	return []byte(passphrase), nil
+}
+
+func findEnvironmentElement(doc *etree.Document, name string) *etree.Element {
+	for _, env := range doc.FindElements("//env") {
+		if strings.EqualFold(env.SelectAttrValue("name", constant.Undefined), name) {
+			return env
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package view
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/xog"
+)
+
+func discoverCommand(args []string, version string) int {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	objectCode := flags.String("object", constant.Undefined, "code of the object to discover the dependencies")
+	sourceName := flags.String("source", constant.Undefined, "name of the environment used for reading")
+	output := flags.String("output", constant.Undefined, "path to save the driver created, default drivers/discovered/<object>.driver")
+	includeStandard := flags.Bool("include-standard", false, "include the lookups, portlets and pages delivered with the product")
+	flags.StringVar(&unattended.username, "username", constant.Undefined, "username for environments without credentials in xogEnv.xml")
+	flags.StringVar(&unattended.password, "password", constant.Undefined, "password for environments without credentials in xogEnv.xml")
+	addCassetteFlags(flags)
+	addLogFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return constant.ExitCodeUsage
+	}
+	if *objectCode == constant.Undefined || *sourceName == constant.Undefined {
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: command discover requires the flags --object and --source\n")
+		flags.Usage()
+		return constant.ExitCodeUsage
+	}
+	if *output == constant.Undefined {
+		*output = "drivers/discovered/" + *objectCode + ".driver"
+	}
+
+	err := initialize(version)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+
+	sourceIndex := environments.IndexByName(*sourceName)
+	if sourceIndex < 0 {
+		log.Error("\n[CAS-XOG][red[ERROR]] - invalid source environment: %s\n", *sourceName)
+		return constant.ExitCodeUsage
+	}
+	err = loginEnvironment(environments.Source, sourceIndex)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeError
+	}
+	defer environments.Logout(util.SoapCall)
+
+	log.Info("\n[CAS-XOG][blue[Discovering]] dependencies of object: %s\n", *objectCode)
+	discovery := &xog.Discovery{Env: environments.Source, Soap: util.SoapCall, IncludeStandard: *includeStandard}
+	dependencies, err := discovery.DiscoverObject(*objectCode)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeError
+	}
+
+	for _, d := range dependencies {
+		code := d.Code
+		if d.ObjectCode != constant.Undefined {
+			code = d.ObjectCode + "/" + d.Code
+		}
+		log.Info("[CAS-XOG][green[Found]] %s: %s | %s\n", d.Type, code, d.Reason)
+	}
+
+	err = xog.SaveDiscoveredDriver(*output, *objectCode, environments.Source.Name, dependencies)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeError
+	}
+	log.Info("\n[CAS-XOG][blue[Driver created]]: %s | Total files: [green[%d]]\n", *output, len(dependencies))
+	return constant.ExitCodeSuccess
+}
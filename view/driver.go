@@ -3,100 +3,375 @@ package view
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/andreluzz/cas-xog/api"
+	"github.com/andreluzz/cas-xog/checkpoint"
 	"github.com/andreluzz/cas-xog/constant"
 	"github.com/andreluzz/cas-xog/log"
 	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/report"
 	"github.com/andreluzz/cas-xog/util"
 	"github.com/andreluzz/cas-xog/xog"
 )
 
-//ProcessDriverFiles displays the feedback of drivers processing
-func ProcessDriverFiles(driver *model.Driver, action string, environments *model.Environments) {
-	start := time.Now()
-
-	outputResults := map[string]int{constant.OutputSuccess: 0, constant.OutputWarning: 0, constant.OutputError: 0, constant.OutputIgnored: 0}
+//ProcessDriverFiles displays the feedback of drivers processing and returns the report suite with the results
+func ProcessDriverFiles(driver *model.Driver, action string, environments *model.Environments) *report.Suite {
+	suite := report.NewSuite(driver.FilePath+" ("+util.GetActionLabel(action)+")", driver.FilePath, action, environments)
+	start := suite.Started
 
 	log.Info("\n------------------------------------------------------------------")
 	log.Info("\n[blue[Initiated at]]: %s", start.Format("Mon _2 Jan 2006 - 15:04:05"))
 	log.Info("\nProcessing driver: %s", driver.FilePath)
 	log.Info("\n------------------------------------------------------------------\n")
 
+	resumeRun := resume && !dryRun
+	if !unattended.enabled && !dryRun && checkpoint.Exists(driver.FilePath, action) {
+		log.Warn("\n[CAS-XOG][yellow[Warning]]: The previous execution of this driver did not finish successfully!")
+		resumeRun = confirm("Do you want to resume it skipping the files already processed?")
+	}
+
+	var journal *checkpoint.Journal
+	if !dryRun {
+		var err error
+		journal, err = checkpoint.Open(driver.FilePath, action, resumeRun)
+		if err != nil {
+			log.Warn("\n[CAS-XOG][yellow[Warning]]: %s\n", err.Error())
+		}
+	}
+	if resumeRun {
+		log.Info("\n[CAS-XOG][blue[Resuming]]: %d files already processed will be skipped\n", journal.Total())
+	}
+
 	processingString := "processing  "
 	if action == "r" {
-		os.RemoveAll(constant.FolderRead)
-		os.MkdirAll(constant.FolderRead, os.ModePerm)
-		os.RemoveAll(constant.FolderWrite)
-		os.MkdirAll(constant.FolderWrite, os.ModePerm)
-	} else if action == "w" {
-		os.RemoveAll(constant.FolderDebug)
-		os.MkdirAll(constant.FolderDebug, os.ModePerm)
+		if !resumeRun {
+			os.RemoveAll(constant.FolderRead)
+			os.MkdirAll(constant.FolderRead, os.ModePerm)
+			os.RemoveAll(constant.FolderWrite)
+			os.MkdirAll(constant.FolderWrite, os.ModePerm)
+		}
+	} else if action == "w" || action == constant.Rollback {
+		if !resumeRun {
+			os.RemoveAll(constant.FolderDebug)
+			os.MkdirAll(constant.FolderDebug, os.ModePerm)
+		}
+		if dryRun {
+			os.RemoveAll(constant.FolderDryRun)
+			os.MkdirAll(constant.FolderDryRun, os.ModePerm)
+			log.Info("\n[CAS-XOG][yellow[Dry-run]]: requests will be saved to %s without calling the environment\n", constant.FolderDryRun)
+		}
 		processingString = "processing   "
 	} else if action == "m" {
-		os.RemoveAll(constant.FolderMigration)
-		os.MkdirAll(constant.FolderMigration, os.ModePerm)
+		if !resumeRun {
+			os.RemoveAll(constant.FolderMigration)
+			os.MkdirAll(constant.FolderMigration, os.ModePerm)
+		}
 		processingString = "processing    "
 	}
 
-	total := len(driver.Files)
-	typePadLength := driver.MaxTypeNameLen()
+	workers := driver.Concurrency
+	if concurrency > 0 {
+		workers = concurrency
+	}
+	if workers > 1 {
+		log.Info("\n[CAS-XOG][blue[Concurrency]]: %d workers\n", workers)
+	}
 
-	for i, f := range driver.Files {
-		formattedType := util.RightPad(f.GetXMLType(), " ", typePadLength)
-		if f.IgnoreReading && action == "r" {
-			log.Info("\n[CAS-XOG][yellow[Read ignored]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path)
-			outputResults[constant.OutputIgnored]++
-			continue
+	p := &driverProcessor{
+		driver:           driver,
+		action:           action,
+		environments:     environments,
+		suite:            suite,
+		journal:          journal,
+		processingString: processingString,
+		typePadLength:    driver.MaxTypeNameLen(),
+		concurrent:       workers > 1,
+	}
+	var b *xog.Backup
+	if action == constant.Write && (driver.Backup || backupWrites) && !dryRun {
+		b = xog.NewBackup(driver.FilePath, environments.Target.Name)
+		xog.SetBackup(b)
+		log.Info("\n[CAS-XOG][blue[Backup]]: the target state will be saved to %s before each write\n", b.Folder)
+	}
+
+	p.run(workers)
+
+	if b != nil {
+		xog.SetBackup(nil)
+		err := b.Close()
+		if err != nil {
+			log.Error("\n[CAS-XOG][red[ERROR]] - error saving backup: %s\n", err.Error())
+		} else if b.Total() > 0 || len(b.Created()) > 0 {
+			log.Info("\n\n[CAS-XOG][blue[Backup saved]]: %s | Total files: %d | Use action 'b' to rollback", b.Folder, b.Total())
+			for _, c := range b.Created() {
+				log.Warn("\n[CAS-XOG][yellow[Warning]]: %s did not exist before the write and cannot be removed by a rollback", c)
+			}
 		}
-		sourceFolder, outputFolder := xog.CreateFileFolder(action, f.Type, f.Path)
+	}
 
-		if f.Type == constant.TypeMigration {
-			sourceFolder = constant.FolderMigration
+	suite.Finish()
+	outputResults := suite.Results
+
+	if outputResults[constant.OutputError] == 0 {
+		journal.Remove()
+	} else {
+		journal.Close()
+		if journal != nil {
+			log.Info("\n\n[CAS-XOG][yellow[Checkpoint saved]]: execute the driver again to resume from the files with errors")
 		}
+	}
 
-		if f.RestAPI() {
-			if action == "w" && f.ExcelFile != "" {
-				sourceFolder = constant.FolderMigration
-			}
-			log.Info("\n[CAS-XOG][blue[%s]] %03d/%03d | [blue[%s]] | file: %s", processingString, i+1, total, formattedType, f.Path)
-			output := api.ProcessDriverFile(&f, action, sourceFolder, outputFolder, environments, util.RestCall)
-			status, color := util.GetStatusColorFromOutput(output.Code)
-			log.Info("\r[CAS-XOG][%s[%s %s]] %03d/%03d | [blue[%s]] | file: %s %s", color, util.GetActionLabel(action), status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
-			outputResults[output.Code]++
-		} else {
-			splitFilename, _ := f.GetSplitWriteFilesPath(sourceFolder)
-			if len(splitFilename) > 0 {
-				totalSplit := len(splitFilename)
-				for j, filename := range splitFilename {
-					f.Path = filename
-					log.Info("\n[CAS-XOG][blue[%s]] %03d/%03d | [blue[%s]] | Split: %03d/%03d | file: %s", processingString, i+1, total, formattedType, j+1, totalSplit, f.Path)
-					output := xog.ProcessDriverFile(&f, action, sourceFolder, outputFolder, environments, util.SoapCall)
-					status, color := util.GetStatusColorFromOutput(output.Code)
-					log.Info("\r[CAS-XOG][%s[%s %s]] %03d/%03d | [blue[%s]] | Split: %03d/%03d | file: %s %s", color, util.GetActionLabel(action), status, i+1, total, formattedType, j+1, totalSplit, f.Path, util.GetOutputDebug(output.Code, output.Debug))
-					outputResults[output.Code]++
+	totalFilesProcessed := outputResults[constant.OutputError] + outputResults[constant.OutputSuccess] + outputResults[constant.OutputWarning] + outputResults[constant.OutputIgnored]
+
+	log.Info("\n\n-----------------------------------------------------------------------------")
+	log.Info("\nStats: total = %d | failure = %d | success = %d | warning = %d | ignored = %d", totalFilesProcessed, outputResults[constant.OutputError], outputResults[constant.OutputSuccess], outputResults[constant.OutputWarning], outputResults[constant.OutputIgnored])
+	log.Info("\n[blue[Concluded in]]: %.3f seconds", suite.Elapsed)
+	log.Info("\n-----------------------------------------------------------------------------\n")
+
+	return suite
+}
+
+type driverProcessor struct {
+	mutex            sync.Mutex
+	driver           *model.Driver
+	action           string
+	environments     *model.Environments
+	suite            *report.Suite
+	journal          *checkpoint.Journal
+	processingString string
+	typePadLength    int
+	concurrent       bool
+}
+
+//run processes the driver files with a pool of workers. Each file waits for the files it depends on before being processed
+func (p *driverProcessor) run(workers int) {
+	runWorkers(p.driver, workers, func(i int) {
+		p.process(i, p.driver.Files[i])
+	})
+}
+
+//runWorkers calls process for each file of the driver with a pool of workers, after the files returned by Dependencies are processed.
+//Files with the same path are never processed at the same time, they share the read, write and debug files. The state set with xog.SetBackup
+//and util.SetCassette must not change while the workers are running
+func runWorkers(driver *model.Driver, workers int, process func(i int)) {
+	if workers <= 1 {
+		for i := range driver.Files {
+			process(i)
+		}
+		return
+	}
+
+	done := make([]chan struct{}, len(driver.Files))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				for _, d := range driver.Dependencies(i) {
+					<-done[d]
 				}
-			} else {
-				log.Info("\n[CAS-XOG][blue[%s]] %03d/%03d | [blue[%s]] | file: %s", processingString, i+1, total, formattedType, f.Path)
+				process(i)
+				close(done[i])
+			}
+		}()
+	}
+
+	for i := range driver.Files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+//started prints the processing line of a file. Omitted when running concurrently because the line would not be replaced by its result
+func (p *driverProcessor) started(format string, args ...interface{}) {
+	if !p.concurrent {
+		log.Info(format, args...)
+	}
+}
+
+//finished registers the output of a file in the report suite and in the checkpoint journal and prints its result line
+func (p *driverProcessor) finished(key, xmlType, path string, output model.Output, elapsed time.Duration, format string, args ...interface{}) {
+	if key != constant.Undefined && (output.Code == constant.OutputSuccess || output.Code == constant.OutputWarning) {
+		err := p.journal.Register(key)
+		if err != nil {
+			log.Warn("\n[CAS-XOG][yellow[Warning]]: %s", err.Error())
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.suite.Add(xmlType, path, output, elapsed)
+	if p.concurrent {
+		format = "\n" + strings.TrimPrefix(format, "\r")
+	}
+	fields := log.Fields{Driver: p.driver.FilePath, File: path, Type: xmlType, Environment: p.environmentName(), Duration: elapsed}
+	log.Print(outputLevel(output.Code), fields, format, args...)
+}
+
+//environmentName returns the name of the environment the files are read from or written to
+func (p *driverProcessor) environmentName() string {
+	if p.environments == nil {
+		return constant.Undefined
+	}
+	env := p.environments.Target
+	if p.action == constant.Read || p.action == constant.Migrate {
+		env = p.environments.Source
+	}
+	if env == nil {
+		return constant.Undefined
+	}
+	return env.Name
+}
 
-				output := xog.ProcessDriverFile(&f, action, sourceFolder, outputFolder, environments, util.SoapCall)
+func outputLevel(code string) log.Level {
+	switch code {
+	case constant.OutputError:
+		return log.LevelError
+	case constant.OutputWarning:
+		return log.LevelWarn
+	}
+	return log.LevelInfo
+}
+
+//resumed registers as ignored an entry completed in a previous run of the driver
+func (p *driverProcessor) resumed(key, xmlType, path, format string, args ...interface{}) bool {
+	if !p.journal.Completed(key) {
+		return false
+	}
+	p.finished(constant.Undefined, xmlType, path, model.Output{Code: constant.OutputIgnored, Debug: "completed in a previous run"}, 0, format, args...)
+	return true
+}
+
+func (p *driverProcessor) process(i int, f model.DriverFile) {
+	action := p.action
+	environments := p.environments
+	processingString := p.processingString
+	total := len(p.driver.Files)
+
+	formattedType := util.RightPad(f.GetXMLType(), " ", p.typePadLength)
+	if f.IgnoreReading && action == "r" {
+		p.finished(constant.Undefined, f.GetXMLType(), f.Path, model.Output{Code: constant.OutputIgnored, Debug: "read ignored"}, 0, "\n[CAS-XOG][yellow[Read ignored]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path)
+		return
+	}
+	if action == constant.Rollback {
+		action = constant.Write
+	}
+	sourceFolder, outputFolder := xog.CreateFileFolder(action, f.Type, f.Path)
+	if p.action == constant.Rollback {
+		sourceFolder = filepath.Dir(p.driver.FilePath) + "/"
+	}
+
+	if f.Type == constant.TypeMigration {
+		sourceFolder = constant.FolderMigration
+	}
+
+	if f.RestAPI() {
+		if action == "w" && f.ExcelFile != "" {
+			sourceFolder = constant.FolderMigration
+		}
+		key := checkpoint.Key(i, f.GetXMLType(), f.Path)
+		if p.resumed(key, f.GetXMLType(), f.Path, "\n[CAS-XOG][yellow[Resume skipped]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path) {
+			return
+		}
+		p.started("\n[CAS-XOG][blue[%s]] %03d/%03d | [blue[%s]] | file: %s", processingString, i+1, total, formattedType, f.Path)
+		fileStart := time.Now()
+		output := processRestDriverFile(&f, action, sourceFolder, outputFolder, environments)
+		status, color := util.GetStatusColorFromOutput(output.Code)
+		p.finished(key, f.GetXMLType(), f.Path, output, time.Since(fileStart), "\r[CAS-XOG][%s[%s %s]] %03d/%03d | [blue[%s]] | file: %s %s", color, util.GetActionLabel(action), status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
+	} else {
+		splitFilename, _ := f.GetSplitWriteFilesPath(sourceFolder)
+		if len(splitFilename) > 0 {
+			totalSplit := len(splitFilename)
+			for j, filename := range splitFilename {
+				f.Path = filename
+				key := checkpoint.Key(i, f.GetXMLType(), filename)
+				if p.resumed(key, f.GetXMLType(), filename, "\n[CAS-XOG][yellow[Resume skipped]] %03d/%03d | [blue[%s]] | Split: %03d/%03d | file: %s", i+1, total, formattedType, j+1, totalSplit, filename) {
+					continue
+				}
+				p.started("\n[CAS-XOG][blue[%s]] %03d/%03d | [blue[%s]] | Split: %03d/%03d | file: %s", processingString, i+1, total, formattedType, j+1, totalSplit, f.Path)
+				fileStart := time.Now()
+				output := xog.ProcessDriverFile(&f, action, sourceFolder, outputFolder, environments, soapFunction(f.Type, filename))
+				output = dryRunOutput(output, f.Type, filename)
 				status, color := util.GetStatusColorFromOutput(output.Code)
-				log.Info("\r[CAS-XOG][%s[%s %s]] %03d/%03d | [blue[%s]] | file: %s %s", color, util.GetActionLabel(action), status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
-				outputResults[output.Code]++
+				p.finished(key, f.GetXMLType(), filename, output, time.Since(fileStart), "\r[CAS-XOG][%s[%s %s]] %03d/%03d | [blue[%s]] | Split: %03d/%03d | file: %s %s", color, util.GetActionLabel(action), status, i+1, total, formattedType, j+1, totalSplit, f.Path, util.GetOutputDebug(output.Code, output.Debug))
+			}
+		} else {
+			path := f.Path
+			key := checkpoint.Key(i, f.GetXMLType(), path)
+			if p.resumed(key, f.GetXMLType(), path, "\n[CAS-XOG][yellow[Resume skipped]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, path) {
+				return
 			}
+			p.started("\n[CAS-XOG][blue[%s]] %03d/%03d | [blue[%s]] | file: %s", processingString, i+1, total, formattedType, f.Path)
+
+			fileStart := time.Now()
+			output := xog.ProcessDriverFile(&f, action, sourceFolder, outputFolder, environments, soapFunction(f.Type, path))
+			output = dryRunOutput(output, f.Type, path)
+			status, color := util.GetStatusColorFromOutput(output.Code)
+			p.finished(key, f.GetXMLType(), path, output, time.Since(fileStart), "\r[CAS-XOG][%s[%s %s]] %03d/%03d | [blue[%s]] | file: %s %s", color, util.GetActionLabel(action), status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
 		}
 	}
+}
 
-	elapsed := time.Since(start)
+//processRestDriverFile executes a rest api driver file, registering the requests sequence instead of calling the environment when in dry-run
+func processRestDriverFile(file *model.DriverFile, action, sourceFolder, outputFolder string, environments *model.Environments) model.Output {
+	if !dryRun {
+		return api.ProcessDriverFile(file, action, sourceFolder, outputFolder, environments, util.RestCall)
+	}
 
-	totalFilesProcessed := outputResults[constant.OutputError] + outputResults[constant.OutputSuccess] + outputResults[constant.OutputWarning] + outputResults[constant.OutputIgnored]
+	dryRunRest := &util.DryRunRest{}
+	output := api.ProcessDriverFile(file, action, sourceFolder, outputFolder, environments, dryRunRest.Call)
+	path := util.GetPathWithoutExtension(file.Path) + "_requests.json"
+	err := dryRunRest.Save(dryRunPath(file.Type, path))
+	if err != nil {
+		return model.Output{Code: constant.OutputError, Debug: err.Error()}
+	}
+	return dryRunOutput(output, file.Type, path)
+}
 
-	log.Info("\n\n-----------------------------------------------------------------------------")
-	log.Info("\nStats: total = %d | failure = %d | success = %d | warning = %d | ignored = %d", totalFilesProcessed, outputResults[constant.OutputError], outputResults[constant.OutputSuccess], outputResults[constant.OutputWarning], outputResults[constant.OutputIgnored])
-	log.Info("\n[blue[Concluded in]]: %.3f seconds", elapsed.Seconds())
-	log.Info("\n-----------------------------------------------------------------------------\n")
+//soapFunction returns the soap function used to execute the file, saving the envelope to the dry-run folder when enabled
+func soapFunction(fileType, path string) util.Soap {
+	if dryRun {
+		return util.DryRunSoap(dryRunPath(fileType, path))
+	}
+	return util.SoapCall
+}
+
+func dryRunPath(fileType, path string) string {
+	return constant.FolderDryRun + fileType + "/" + path
+}
+
+func dryRunOutput(output model.Output, fileType, path string) model.Output {
+	if dryRun && output.Code == constant.OutputSuccess {
+		output.Debug = "| Dry-run: " + dryRunPath(fileType, path)
+	}
+	return output
+}
+
+//saveReport writes the JSON and JUnit XML report with the suites results
+func saveReport(path string, suites ...*report.Suite) {
+	r := &report.Report{}
+	r.Add(suites...)
+	if path == constant.Undefined {
+		path = report.DefaultPath()
+	}
+	err := r.Save(path)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return
+	}
+	log.Info("\n[CAS-XOG][blue[Report saved]]: %s.json | %s.xml\n", path, path)
 }
 
 func renderDrivers() {
@@ -104,7 +379,7 @@ func renderDrivers() {
 	currentFolder := constant.Undefined
 	driversList, err := xog.GetDriversList(folder)
 	if err != nil {
-		log.Info("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
 		return
 	}
 
@@ -132,7 +407,7 @@ func renderDrivers() {
 	}
 
 	input := "1"
-	fmt.Scanln(&input)
+	scanLine(&input)
 
 	if input == "p" && startInstallingPackage == 0 {
 		startInstallingPackage = 1
@@ -143,13 +418,15 @@ func renderDrivers() {
 	driverIndex, err := strconv.Atoi(input)
 
 	if err != nil || driverIndex-1 < 0 || driverIndex > len(driversList) {
-		log.Info("\n[CAS-XOG][red[ERROR]] - Invalid driver!\n")
+		log.Error("\n[CAS-XOG][red[ERROR]] - Invalid driver!\n")
 		return
 	}
 
-	total, err := xog.LoadDriver(driversList[driverIndex-1].FilePath)
+	selectedDriverPath = driversList[driverIndex-1].FilePath
+	total, err := xog.LoadDriver(selectedDriverPath)
 	if err != nil {
-		log.Info("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		log.Info("[CAS-XOG]Try action 'v' to validate the driver and list all its issues.\n")
 		return
 	}
 
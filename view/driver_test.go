@@ -0,0 +1,99 @@
+package view
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/xog"
+)
+
+func TestRunWorkersWithBackup(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	folder, _ := ioutil.TempDir("", "cas-xog-workers")
+	defer os.RemoveAll(folder)
+	defer os.RemoveAll(constant.FolderBackup)
+	defer os.RemoveAll(constant.FolderDebug)
+
+	sourceFolder := folder + "/write/"
+	outputFolder := folder + "/debug/"
+	util.ValidateFolder(sourceFolder + constant.TypeLookup)
+	util.ValidateFolder(outputFolder + constant.TypeLookup)
+	data, _ := ioutil.ReadFile("../mock/xog/soap/Lookups/lookup_cas_xog_1.xml")
+
+	driver := &model.Driver{}
+	for _, code := range []string{"LOOKUP_1", "LOOKUP_2", "LOOKUP_3", "LOOKUP_4"} {
+		path := strings.ToLower(code) + ".xml"
+		ioutil.WriteFile(filepath.Join(sourceFolder, constant.TypeLookup, path), data, os.ModePerm)
+		driver.Files = append(driver.Files, model.DriverFile{Type: constant.TypeLookup, Code: code, Path: path})
+	}
+	//the same path is written again and must wait for the first write
+	driver.Files = append(driver.Files, model.DriverFile{Type: constant.TypeLookup, Code: "LOOKUP_1", Path: "lookup_1.xml"})
+
+	environments := &model.Environments{Target: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"}}
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		time.Sleep(5 * time.Millisecond)
+		mock := "../mock/xog/soap/soap_success_write_response.xml"
+		if strings.Contains(request, "<LookupQuery>") {
+			mock = "../mock/xog/soap/soap_success_read_response.xml"
+		}
+		file, _ := ioutil.ReadFile(mock)
+		return util.BytesToString(file), nil
+	}
+
+	b := xog.NewBackup("workers.driver", "DEV")
+	xog.SetBackup(b)
+	defer xog.SetBackup(nil)
+
+	var mutex sync.Mutex
+	active := map[string]int{}
+	running, maxRunning := 0, 0
+	finished := make([]bool, len(driver.Files))
+	errors := []string{}
+	runWorkers(driver, 3, func(i int) {
+		f := driver.Files[i]
+		mutex.Lock()
+		active[f.Path]++
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		if active[f.Path] > 1 {
+			errors = append(errors, "file "+f.Path+" processed at the same time by two workers")
+		}
+		for _, d := range driver.Dependencies(i) {
+			if !finished[d] {
+				errors = append(errors, "file "+f.Path+" processed before its dependency "+driver.Files[d].Path)
+			}
+		}
+		mutex.Unlock()
+
+		output := xog.ProcessDriverFile(&f, constant.Write, sourceFolder, outputFolder, environments, soapMock)
+
+		mutex.Lock()
+		if output.Code != constant.OutputSuccess {
+			errors = append(errors, "file "+f.Path+" with error: "+output.Debug)
+		}
+		active[f.Path]--
+		running--
+		finished[i] = true
+		mutex.Unlock()
+	})
+
+	for _, e := range errors {
+		t.Errorf("Error processing files with workers. Debug: %s", e)
+	}
+	if maxRunning < 2 {
+		t.Errorf("Error processing files with workers, files not processed concurrently")
+	}
+	if b.Total() != 4 {
+		t.Errorf("Error processing files with workers, expected 4 files in the backup received %d", b.Total())
+	}
+}
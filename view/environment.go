@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/andreluzz/cas-xog/constant"
 	"github.com/andreluzz/cas-xog/log"
 	"github.com/andreluzz/cas-xog/model"
 	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/xog"
 	"github.com/howeyc/gopass"
 )
 
@@ -16,7 +18,7 @@ var targetEnvInput string
 
 //Environments displays the options for the user to choose the environment for reading and writing
 func Environments(action string, environments *model.Environments) bool {
-	if action == "m" {
+	if action == "m" && !xog.MigrationNeedsTarget() {
 		return true
 	}
 
@@ -28,10 +30,10 @@ func Environments(action string, environments *model.Environments) bool {
 	log.Info("Available environments:\n")
 
 	if environments.Available == nil || len(environments.Available) == 0 {
-		log.Info("\n[CAS-XOG][red[ERROR]] - None available environments found!\n")
-		log.Info("\n[CAS-XOG][red[FATAL]] - Check your xogEnv.xml file. Press enter key to exit...")
+		log.Error("\n[CAS-XOG][red[ERROR]] - None available environments found!\n")
+		log.Error("\n[CAS-XOG][red[FATAL]] - Check your xogEnv.xml file. Press enter key to exit...")
 		scanExit := ""
-		fmt.Scanln(&scanExit)
+		scanLine(&scanExit)
 		os.Exit(0)
 	}
 
@@ -39,14 +41,20 @@ func Environments(action string, environments *model.Environments) bool {
 		log.Info("%d - %s\n", i+1, e.Name)
 	}
 
-	if action == "r" {
+	if action == "r" || action == constant.Compare {
 		sourceInput, result = processingChooseEnvironment(environments, constant.Source, "reading", constant.Undefined)
 		if result == false {
 			return false
 		}
 	}
 
-	targetInput, result = processingChooseEnvironment(environments, constant.Target, "writing", sourceInput)
+	targetLabel := "writing"
+	if action == constant.Compare {
+		targetLabel = "comparing"
+	} else if action == constant.Migrate {
+		targetLabel = "validating"
+	}
+	targetInput, result = processingChooseEnvironment(environments, constant.Target, targetLabel, sourceInput)
 	if result == false {
 		return false
 	}
@@ -56,11 +64,8 @@ func Environments(action string, environments *model.Environments) bool {
 	}
 
 	if action == "w" && targetEnvInput != targetInput {
-		log.Info("\n[CAS-XOG][yellow[Warning]]: Trying to write files read from a different target environment!")
-		log.Info("\n[CAS-XOG]Do you want to continue anyway? (y = Yes, n = No) [n]: ")
-		input := "n"
-		fmt.Scanln(&input)
-		if input != "y" {
+		log.Warn("\n[CAS-XOG][yellow[Warning]]: Trying to write files read from a different target environment!")
+		if !confirm("Do you want to continue anyway?") {
 			return false
 		}
 	}
@@ -71,13 +76,13 @@ func Environments(action string, environments *model.Environments) bool {
 func processingChooseEnvironment(environments *model.Environments, envType, label, lastEnvInput string) (string, bool) {
 	log.Info("Choose %s environment [1]: ", label)
 	userScanInput := "1"
-	fmt.Scanln(&userScanInput)
+	scanLine(&userScanInput)
 
 	envIndex, err := strconv.Atoi(userScanInput)
 	envIndex--
 
 	if err != nil || envIndex < 0 || envIndex >= len(environments.Available) {
-		log.Info("\n[CAS-XOG][red[ERROR]] - Invalid reading environment index!\n")
+		log.Error("\n[CAS-XOG][red[ERROR]] - Invalid reading environment index!\n")
 		return userScanInput, false
 	}
 	var env *model.EnvType
@@ -93,32 +98,101 @@ func processingChooseEnvironment(environments *model.Environments, envType, labe
 		return userScanInput, true
 	}
 
-	env.Init(envIndex)
+	err = loginEnvironment(env, envIndex)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s", err.Error())
+		log.Error("\n[CAS-XOG][red[FATAL]] - Check your xogEnv.xml file. Press enter key to exit...")
+		scanExit := ""
+		scanLine(&scanExit)
+		os.Exit(0)
+	}
+
+	return userScanInput, true
+}
+
+//resolveVariables resolves the driver variables with the target environment, only when the action selected one.
+//A migration that does not validate against the target has no environment, so any variable defined only in the environments fails
+func resolveVariables(action string, environments *model.Environments) error {
+	if action == constant.Migrate && !xog.MigrationNeedsTarget() {
+		return xog.ResolveEnvironmentVariables(nil)
+	}
+	return xog.ResolveEnvironmentVariables(environments.Target)
+}
+
+//selectEnvironmentsByName loads and logs into the source and target environments without user interaction
+func selectEnvironmentsByName(action, sourceName, targetName string, environments *model.Environments) error {
+	if action == constant.Migrate {
+		if !xog.MigrationNeedsTarget() {
+			return nil
+		}
+		if targetName == constant.Undefined {
+			return fmt.Errorf("action %s with validateObject requires the flag --target", action)
+		}
+	}
+
+	if action == constant.Read || action == constant.Compare {
+		sourceIndex := environments.IndexByName(sourceName)
+		if sourceIndex < 0 {
+			return fmt.Errorf("invalid source environment: %s", sourceName)
+		}
+		err := loginEnvironment(environments.Source, sourceIndex)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(sourceName, targetName) {
+			environments.CopyTargetFromSource()
+			return nil
+		}
+	}
+
+	targetIndex := environments.IndexByName(targetName)
+	if targetIndex < 0 {
+		return fmt.Errorf("invalid target environment: %s", targetName)
+	}
+	return loginEnvironment(environments.Target, targetIndex)
+}
+
+func loginEnvironment(env *model.EnvType, envIndex int) error {
+	err := env.Init(envIndex)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		log.Info("[CAS-XOG][yellow[Dry-run login skipped]] - Environment: %s \n", env.Name)
+		return nil
+	}
 	if env.RequestLogin {
-		requestLogin(env)
+		err := requestLogin(env)
+		if err != nil {
+			return err
+		}
 	}
 	log.Info("[CAS-XOG]Processing environment login")
 	err = env.Login(envIndex, util.SoapCall, util.RestCall)
-
 	if err != nil {
-		log.Info("\n[CAS-XOG][red[ERROR]] - %s", err.Error())
-		log.Info("\n[CAS-XOG][red[FATAL]] - Check your xogEnv.xml file. Press enter key to exit...")
-		scanExit := ""
-		fmt.Scanln(&scanExit)
-		os.Exit(0)
+		return err
 	}
 	log.Info("\r[CAS-XOG][green[Login successfully]] - Environment: %s \n", env.Name)
-
-	return userScanInput, true
+	return nil
 }
 
-func requestLogin(envType *model.EnvType) {
+func requestLogin(envType *model.EnvType) error {
+	if unattended.enabled {
+		if unattended.username == constant.Undefined || unattended.password == constant.Undefined {
+			return fmt.Errorf("no credentials defined for environment: %s", envType.Name)
+		}
+		envType.Username = unattended.username
+		envType.Password = unattended.password
+		return nil
+	}
+
 	log.Info("\n[CAS-XOG][yellow[Login needed]] - Enter credentials for environment: %s \n", envType.Name)
 	log.Info("Username: ")
-	fmt.Scanln(&envType.Username)
+	scanLine(&envType.Username)
 
 	log.Info("Password: ")
 	passwordTemp, _ := gopass.GetPasswdMasked()
 	envType.Password = string(passwordTemp[:])
 	log.Info("\n")
+	return nil
 }
@@ -0,0 +1,35 @@
+package view
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/xog"
+)
+
+func TestResolveVariablesForMigrationWithoutTarget(t *testing.T) {
+	environments, err := model.LoadEnvironmentsList("../mock/xog/variables/xogEnv.xml")
+	if err != nil {
+		t.Fatalf("Error loading environments. Debug: %s", err.Error())
+	}
+	_, err = xog.LoadDriver("../mock/xog/variables/environment.driver")
+	if err != nil {
+		t.Fatalf("Error loading driver with environment variables. Debug: %s", err.Error())
+	}
+
+	environments.Target = environments.Available[0]
+	err = resolveVariables(constant.Migrate, environments)
+	if err == nil || !strings.Contains(err.Error(), "no environment was selected") {
+		t.Errorf("Error resolving variables. Migration without target resolved the environment variables. Debug: %v", err)
+	}
+
+	err = resolveVariables(constant.Write, environments)
+	if err != nil {
+		t.Fatalf("Error resolving variables. Debug: %s", err.Error())
+	}
+	if view := xog.GetLoadedDriver().Files[0]; view.TargetPartition != "DEV_PARTITION" {
+		t.Errorf("Error resolving variables. Expected DEV_PARTITION received %s", view.TargetPartition)
+	}
+}
@@ -0,0 +1,39 @@
+package view
+
+import (
+	"flag"
+
+	"github.com/andreluzz/cas-xog/log"
+)
+
+//logSettings stores the log flags of the commands, the interactive mode uses the default values
+var logSettings = struct {
+	level      string
+	format     string
+	stdout     bool
+	maxSize    int
+	maxBackups int
+}{"debug", log.FormatText, false, 10, 5}
+
+func addLogFlags(flags *flag.FlagSet) {
+	flags.StringVar(&logSettings.level, "log-level", logSettings.level, "minimum level of the log records: error, warn, info, debug or trace")
+	flags.StringVar(&logSettings.format, "log-format", logSettings.format, "format of the log records: text or json, one record per line")
+	flags.BoolVar(&logSettings.stdout, "log-stdout", logSettings.stdout, "print the log records to stdout without color codes instead of the screen messages, for CI")
+	flags.IntVar(&logSettings.maxSize, "log-max-size", logSettings.maxSize, "size in MB of the log file before it is rotated, 0 disables the rotation")
+	flags.IntVar(&logSettings.maxBackups, "log-max-backups", logSettings.maxBackups, "number of rotated log files kept")
+}
+
+//initLog configures the log with the values of the log flags
+func initLog() error {
+	config := log.DefaultConfig()
+	level, err := log.ParseLevel(logSettings.level)
+	if err != nil {
+		return err
+	}
+	config.Level = level
+	config.Format = logSettings.format
+	config.Stdout = logSettings.stdout
+	config.MaxSize = int64(logSettings.maxSize) * 1024 * 1024
+	config.MaxBackups = logSettings.maxBackups
+	return log.InitLog(config)
+}
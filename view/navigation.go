@@ -1,13 +1,14 @@
 package view
 
 import (
-	"fmt"
+	"bufio"
 	"os"
 	"strings"
 
 	"github.com/andreluzz/cas-xog/constant"
 	"github.com/andreluzz/cas-xog/log"
 	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/secret"
 	"github.com/andreluzz/cas-xog/util"
 	"github.com/andreluzz/cas-xog/xog"
 )
@@ -15,27 +16,50 @@ import (
 var startInstallingPackage int
 var environments *model.Environments
 
+//stdin is the reader shared by all the prompts, a reader for each prompt would lose the input already buffered
+var stdin = bufio.NewReader(os.Stdin)
+
+//scanLine reads the line typed without the spaces around it, so the values can contain spaces. The value is kept when the line is empty
+func scanLine(value *string) {
+	line, _ := stdin.ReadString('\n')
+	if line = strings.TrimSpace(line); line != constant.Undefined {
+		*value = line
+	}
+}
+
 //Home display the system header and initializes variables
 func Home(version string) {
-	var err error
+	err := initialize(version)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[Error]]: %s\n", err.Error())
+	}
 
-	log.InitLog()
+	renderDrivers()
+}
+
+func initialize(version string) error {
+	err := initLog()
+	if err != nil {
+		return err
+	}
 
 	log.Info("\n")
 	log.Info("------------------------------------------------\n")
 	log.Info("##### CAS XOG Automation - Version %s #####\n", version)
 	log.Info("------------------------------------------------\n")
 
+	err = initCassette()
+	if err != nil {
+		return err
+	}
+
 	startInstallingPackage = 0
 
 	model.LoadXMLReadList("xogRead.xml")
+	secret.SetPassphrasePrompt(askPassphrase)
 
 	environments, err = model.LoadEnvironmentsList("xogEnv.xml")
-	if err != nil {
-		log.Info("\n[CAS-XOG][red[Error]]: %s\n", err.Error())
-	}
-
-	renderDrivers()
+	return err
 }
 
 //Interface display the main menu for user to interact and choose available actions
@@ -47,41 +71,66 @@ func Interface() bool {
 		inputAction = "p"
 	} else {
 		log.Info("\nChoose action")
-		log.Info("\n(l = Load Driver, r = Read, w = Write, m = Create Migration, p = Install Package or x = eXit): ")
-		fmt.Scanln(&inputAction)
+		log.Info("\n(l = Load Driver, v = Validate Driver, r = Read, w = Write, m = Create Migration, c = Compare, k = Create Package, p = Install Package, b = Rollback or x = eXit): ")
+		scanLine(&inputAction)
 	}
 
 	action := strings.ToLower(inputAction)
 	switch action {
 	case constant.Write, constant.Read, constant.Migrate:
 		if xog.ValidateLoadedDriver() == false {
-			log.Info("\n[CAS-XOG][red[ERROR]] - Driver not loaded. Try action 'l' to load a valid driver.\n")
+			log.Error("\n[CAS-XOG][red[ERROR]] - Driver not loaded. Try action 'l' to load a valid driver.\n")
 			return false
 		}
 		if !Environments(action, environments) {
 			return false
 		}
+		if err := resolveVariables(action, environments); err != nil {
+			log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+			environments.Logout(util.SoapCall)
+			return false
+		}
 		driver := xog.GetLoadedDriver()
 
 		if action == constant.Read && driver.AutomaticWrite {
-			log.Info("\n[CAS-XOG][yellow[Warning]]: This driver is configured to write automatically!")
-			log.Info("\n[CAS-XOG]Do you want to proceed? (y = Yes, n = No) [n]: ")
-			input := "n"
-			fmt.Scanln(&input)
-			if input != "y" {
-				ProcessDriverFiles(driver, action, environments)
+			log.Warn("\n[CAS-XOG][yellow[Warning]]: This driver is configured to write automatically!")
+			if !confirm("Do you want to proceed?") {
+				saveReport(constant.Undefined, ProcessDriverFiles(driver, action, environments))
 				environments.Logout(util.SoapCall)
 				return false
 			}
 		}
 
-		ProcessDriverFiles(driver, action, environments)
+		suite := ProcessDriverFiles(driver, action, environments)
 
 		if action == constant.Read && driver.AutomaticWrite {
-			ProcessDriverFiles(driver, constant.Write, environments)
+			saveReport(constant.Undefined, suite, ProcessDriverFiles(driver, constant.Write, environments))
+		} else {
+			saveReport(constant.Undefined, suite)
 		}
 
 		environments.Logout(util.SoapCall)
+	case constant.Compare:
+		if xog.ValidateLoadedDriver() == false {
+			log.Error("\n[CAS-XOG][red[ERROR]] - Driver not loaded. Try action 'l' to load a valid driver.\n")
+			return false
+		}
+		if !Environments(action, environments) {
+			return false
+		}
+		if err := resolveVariables(action, environments); err != nil {
+			log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+			environments.Logout(util.SoapCall)
+			return false
+		}
+		saveReport(constant.Undefined, CompareDriverFiles(xog.GetLoadedDriver(), environments, constant.Undefined))
+		environments.Logout(util.SoapCall)
+	case constant.Build:
+		if xog.ValidateLoadedDriver() == false {
+			log.Error("\n[CAS-XOG][red[ERROR]] - Driver not loaded. Try action 'l' to load a valid driver.\n")
+			return false
+		}
+		renderCreatePackage(xog.GetLoadedDriver())
 	case constant.Package:
 		xog.LoadPackages(constant.FolderPackage, "packages/")
 		output, selectedPackage, selectedVersion := renderPackages()
@@ -91,17 +140,26 @@ func Interface() bool {
 		if !Environments(action, environments) {
 			return false
 		}
-		err := InstallPackage(environments, selectedPackage, selectedVersion)
+		suites, err := InstallPackage(environments, selectedPackage, selectedVersion)
 		if err != nil {
-			log.Info("\n[CAS-XOG][red[PACKAGE]]: %s\n", err.Error())
+			log.Error("\n[CAS-XOG][red[PACKAGE]]: %s\n", err.Error())
 			return false
 		}
+		saveReport(constant.Undefined, suites...)
+	case constant.Rollback:
+		renderRollback()
 	case constant.Load:
 		renderDrivers()
+	case constant.Validate:
+		if selectedDriverPath == constant.Undefined {
+			log.Error("\n[CAS-XOG][red[ERROR]] - Driver not selected. Try action 'l' to select a driver.\n")
+			return false
+		}
+		validateDriver(selectedDriverPath)
 	case constant.Exit:
 		log.Info("\n[CAS-XOG][blue[Action exit selected]] - Press enter key to exit...\n")
 		scanExit := ""
-		fmt.Scanln(&scanExit)
+		scanLine(&scanExit)
 		os.Exit(0)
 		return true
 	default:
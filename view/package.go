@@ -1,23 +1,23 @@
 package view
 
 import (
+	"flag"
 	"fmt"
 	"github.com/andreluzz/cas-xog/constant"
 	"github.com/andreluzz/cas-xog/log"
 	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/report"
 	"github.com/andreluzz/cas-xog/util"
 	"github.com/andreluzz/cas-xog/xog"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
-//InstallPackage display the logs from the package's driver that is being installed
-func InstallPackage(environments *model.Environments, selectedPackage *model.Package, selectedVersion *model.Version) error {
-	start := time.Now()
-
-	outputResults := map[string]int{constant.OutputSuccess: 0, constant.OutputWarning: 0, constant.OutputError: 0, constant.OutputIgnored: 0}
-
+//InstallPackage display the logs from the package's driver that is being installed and returns the report suites of processing and installing
+func InstallPackage(environments *model.Environments, selectedPackage *model.Package, selectedVersion *model.Version) ([]*report.Suite, error) {
 	driverPath := constant.FolderPackage + selectedPackage.Folder + selectedPackage.DriverFileName
 	if selectedVersion.DriverFileName != "" {
 		driverPath = constant.FolderPackage + selectedPackage.Folder + selectedVersion.Folder + selectedVersion.DriverFileName
@@ -25,15 +25,28 @@ func InstallPackage(environments *model.Environments, selectedPackage *model.Pac
 
 	total, err := xog.LoadDriver(driverPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	err = xog.ResolveEnvironmentVariables(environments.Target)
+	if err != nil {
+		return nil, err
 	}
 
+	suiteName := selectedPackage.Name + " (" + selectedVersion.Name + ")"
+	processSuite := report.NewSuite(suiteName+" - Process", driverPath, constant.Package, environments)
+	start := processSuite.Started
+	outputResults := processSuite.Results
+
 	os.RemoveAll(constant.FolderDebug)
 	os.MkdirAll(constant.FolderDebug, os.ModePerm)
 	os.RemoveAll(constant.FolderWrite)
 	os.MkdirAll(constant.FolderWrite, os.ModePerm)
 	os.RemoveAll(constant.FolderRead)
 	os.MkdirAll(constant.FolderRead, os.ModePerm)
+	if dryRun {
+		os.RemoveAll(constant.FolderDryRun)
+		os.MkdirAll(constant.FolderDryRun, os.ModePerm)
+	}
 
 	log.Info("\n------------------------------------------------------------------")
 	log.Info("\n[blue[Initiated at]]: %s", start.Format("Mon _2 Jan 2006 - 15:04:05"))
@@ -47,32 +60,37 @@ func InstallPackage(environments *model.Environments, selectedPackage *model.Pac
 		formattedType := util.RightPad(f.GetXMLType(), " ", typePadLength)
 		if f.IgnoreReading {
 			log.Info("\n[CAS-XOG][yellow[Processed ignored]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path)
-			outputResults[constant.OutputIgnored]++
+			processSuite.Add(f.GetXMLType(), f.Path, model.Output{Code: constant.OutputIgnored, Debug: "processing ignored"}, 0)
 			continue
 		}
 		log.Info("\n[CAS-XOG][blue[Processing       ]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path)
 		packageFolder := constant.FolderPackage + selectedPackage.Folder + selectedVersion.Folder + f.Type + "/"
 		writeFolder := constant.FolderWrite + f.Type
-		output := xog.ProcessPackageFile(&f, selectedVersion, packageFolder, writeFolder, environments, util.SoapCall)
+		fileStart := time.Now()
+		output := processPackageFile(f, selectedVersion, packageFolder, writeFolder, environments)
+		processSuite.Add(f.GetXMLType(), f.Path, output, time.Since(fileStart))
 		status, color := util.GetStatusColorFromOutput(output.Code)
 		log.Info("\r[CAS-XOG][%s[Processed %s]] %03d/%03d | [blue[%s]] | file: %s %s", color, status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
-		outputResults[output.Code]++
 	}
 
-	elapsed := time.Since(start)
+	processSuite.Finish()
 
 	log.Info("\n\n-----------------------------------------------------------------------------")
 	log.Info("\nStats: total = %d | failure = %d | success = %d | warning = %d | ignored = %d", total, outputResults[constant.OutputError], outputResults[constant.OutputSuccess], outputResults[constant.OutputWarning], outputResults[constant.OutputIgnored])
-	log.Info("\n[blue[Concluded in]]: %.3f seconds", elapsed.Seconds())
+	log.Info("\n[blue[Concluded in]]: %.3f seconds", processSuite.Elapsed)
 	log.Info("\n-----------------------------------------------------------------------------\n")
 
-	outputResults = map[string]int{constant.OutputSuccess: 0, constant.OutputWarning: 0, constant.OutputError: 0, constant.OutputIgnored: 0}
-	start = time.Now()
+	installSuite := report.NewSuite(suiteName+" - Install", driverPath, constant.Package, environments)
+	outputResults = installSuite.Results
+	start = installSuite.Started
 
 	log.Info("\n------------------------------------------------------------------")
 	log.Info("\n[blue[Initiated at]]: %s", start.Format("Mon _2 Jan 2006 - 15:04:05"))
 	log.Info("\nInstalling Package: [blue[%s]] (%s)", selectedPackage.Name, selectedVersion.Name)
 	log.Info("\nTarget environment: [blue[%s]]", environments.Target.Name)
+	if dryRun {
+		log.Info("\n[yellow[Dry-run]]: requests will be saved to %s without calling the environment", constant.FolderDryRun)
+	}
 	if len(selectedVersion.Definitions) > 0 {
 		log.Info("\nDefinitions: ")
 		for _, d := range selectedVersion.Definitions {
@@ -80,33 +98,48 @@ func InstallPackage(environments *model.Environments, selectedPackage *model.Pac
 		}
 	}
 	log.Info("\n------------------------------------------------------------------\n")
-	log.Info("\n[CAS-XOG]Start package install? (y = Yes, n = No) [n]: ")
-	input := "n"
-	fmt.Scanln(&input)
-	if input != "y" {
-		return nil
+	if !confirm("Start package install?") {
+		return []*report.Suite{processSuite}, nil
 	}
 
-	start = time.Now()
+	installSuite.Started = time.Now()
 
 	for i, f := range driver.Files {
 		formattedType := util.RightPad(f.GetXMLType(), " ", typePadLength)
 		log.Info("\n[CAS-XOG][blue[Installing     ]] %03d/%03d | [blue[%s]] | file: %s", i+1, total, formattedType, f.Path)
-		output := xog.InstallPackageFile(&f, environments, util.SoapCall)
+		fileStart := time.Now()
+		path := f.Path
+		output := xog.InstallPackageFile(&f, environments, soapFunction(f.Type, path))
+		output = dryRunOutput(output, f.Type, path)
+		installSuite.Add(f.GetXMLType(), f.Path, output, time.Since(fileStart))
 		status, color := util.GetStatusColorFromOutput(output.Code)
 		log.Info("\r[CAS-XOG][%s[Install %s]] %03d/%03d | [blue[%s]] | file: %s %s", color, status, i+1, total, formattedType, f.Path, util.GetOutputDebug(output.Code, output.Debug))
-		outputResults[output.Code]++
 	}
 
 	environments.Logout(util.SoapCall)
-	elapsed = time.Since(start)
+	installSuite.Finish()
 
 	log.Info("\n\n------------------------------------------------------------------")
 	log.Info("\nStats: total = %d | failure = %d | success = %d | warning = %d", total, outputResults[constant.OutputError], outputResults[constant.OutputSuccess], outputResults[constant.OutputWarning])
-	log.Info("\n[blue[Concluded in]]: %.3f seconds", elapsed.Seconds())
+	log.Info("\n[blue[Concluded in]]: %.3f seconds", installSuite.Elapsed)
 	log.Info("\n------------------------------------------------------------------\n")
 
-	return nil
+	return []*report.Suite{processSuite, installSuite}, nil
+}
+
+//processPackageFile creates the package write file. In dry-run the files that need the target environment to be transformed are created without the package transform
+func processPackageFile(f model.DriverFile, selectedVersion *model.Version, packageFolder, writeFolder string, environments *model.Environments) model.Output {
+	if !dryRun || !f.PackageTransform || !f.NeedPackageTransform() {
+		return xog.ProcessPackageFile(&f, selectedVersion, packageFolder, writeFolder, environments, util.SoapCall)
+	}
+
+	f.PackageTransform = false
+	output := xog.ProcessPackageFile(&f, selectedVersion, packageFolder, writeFolder, environments, util.SoapCall)
+	if output.Code == constant.OutputSuccess {
+		output.Code = constant.OutputWarning
+		output.Debug = "dry-run cannot read the target environment, package transform not applied"
+	}
+	return output
 }
 
 func renderPackages() (bool, *model.Package, *model.Version) {
@@ -114,7 +147,7 @@ func renderPackages() (bool, *model.Package, *model.Version) {
 	availablePackages := xog.GetAvailablePackages()
 
 	if len(availablePackages) <= 0 {
-		log.Info("\n[CAS-XOG][yellow[WARNING]] - No package available, check your packages folder!\n")
+		log.Warn("\n[CAS-XOG][yellow[WARNING]] - No package available, check your packages folder!\n")
 		return false, nil, nil
 	}
 
@@ -125,7 +158,7 @@ func renderPackages() (bool, *model.Package, *model.Version) {
 	}
 	log.Info("Choose package to install [1] or b = Back to options menu: ")
 	input := "1"
-	fmt.Scanln(&input)
+	scanLine(&input)
 
 	if input == "b" {
 		return false, nil, nil
@@ -134,7 +167,7 @@ func renderPackages() (bool, *model.Package, *model.Version) {
 	packageIndex, err := strconv.Atoi(input)
 
 	if err != nil || packageIndex-1 < 0 || packageIndex > len(availablePackages) {
-		log.Info("\n[CAS-XOG][red[ERROR]] - Invalid package!\n")
+		log.Error("\n[CAS-XOG][red[ERROR]] - Invalid package!\n")
 		return false, nil, nil
 	}
 
@@ -149,11 +182,11 @@ func renderPackages() (bool, *model.Package, *model.Version) {
 		}
 		log.Info("Choose version to install [1]: ")
 		input := "1"
-		fmt.Scanln(&input)
+		scanLine(&input)
 		versionIndex, err = strconv.Atoi(input)
 
 		if err != nil {
-			log.Info("\n[CAS-XOG][red[ERROR]] - Package definition error: %s\n", err.Error())
+			log.Error("\n[CAS-XOG][red[ERROR]] - Package definition error: %s\n", err.Error())
 			return false, nil, nil
 		}
 	}
@@ -164,9 +197,9 @@ func renderPackages() (bool, *model.Package, *model.Version) {
 		for i, d := range selectedVersion.Definitions {
 			log.Info("%s [%s]: ", d.Description, d.Default)
 			input := d.Default
-			fmt.Scanln(&input)
+			scanLine(&input)
 			if input == "" {
-				log.Info("\n[CAS-XOG][red[ERROR]] - Invalid definition!\n")
+				log.Error("\n[CAS-XOG][red[ERROR]] - Invalid definition!\n")
 				return false, nil, nil
 			}
 			selectedVersion.Definitions[i].Value = input
@@ -175,3 +208,148 @@ func renderPackages() (bool, *model.Package, *model.Version) {
 
 	return true, &selectedPackage, &selectedVersion
 }
+
+func selectPackageByName(packageName, versionName string, definitions map[string]string) (*model.Package, *model.Version, error) {
+	var selectedPackage *model.Package
+	for _, p := range xog.GetAvailablePackages() {
+		if strings.EqualFold(p.Name, packageName) {
+			selectedPackage = &p
+			break
+		}
+	}
+	if selectedPackage == nil {
+		return nil, nil, fmt.Errorf("package %s not found, check your packages folder", packageName)
+	}
+
+	if len(selectedPackage.Versions) == 0 {
+		return nil, nil, fmt.Errorf("package %s has no versions defined", selectedPackage.Name)
+	}
+
+	selectedVersion := &selectedPackage.Versions[0]
+	if versionName != constant.Undefined {
+		selectedVersion = nil
+		for i, v := range selectedPackage.Versions {
+			if strings.EqualFold(v.Name, versionName) {
+				selectedVersion = &selectedPackage.Versions[i]
+				break
+			}
+		}
+		if selectedVersion == nil {
+			return nil, nil, fmt.Errorf("package %s has no version %s", selectedPackage.Name, versionName)
+		}
+	} else if len(selectedPackage.Versions) > 1 {
+		return nil, nil, fmt.Errorf("package %s has more than one version, use the flag --package-version", selectedPackage.Name)
+	}
+
+	for i, d := range selectedVersion.Definitions {
+		value, ok := definitions[d.Description]
+		if !ok {
+			value = d.Default
+		}
+		if value == constant.Undefined {
+			return nil, nil, fmt.Errorf("no value for package definition: %s", d.Description)
+		}
+		selectedVersion.Definitions[i].Value = value
+	}
+
+	return selectedPackage, selectedVersion, nil
+}
+
+//renderCreatePackage asks the package manifest definitions and creates the package zip from the loaded driver and its write files
+func renderCreatePackage(driver *model.Driver) bool {
+	name := strings.TrimSuffix(filepath.Base(driver.FilePath), filepath.Ext(driver.FilePath))
+	log.Info("\n[CAS-XOG] [blue[Package definitions]] - Leave empty the definitions not needed\n")
+	log.Info("Package name [%s]: ", name)
+	scanLine(&name)
+	versionName := "Default"
+	log.Info("Version name [%s]: ", versionName)
+	scanLine(&versionName)
+	partitionModel := constant.Undefined
+	log.Info("Default target partition model: ")
+	scanLine(&partitionModel)
+	partition := constant.Undefined
+	log.Info("Default target partition: ")
+	scanLine(&partition)
+	replaces := constant.Undefined
+	log.Info("Strings to replace, separated by commas: ")
+	scanLine(&replaces)
+
+	return createPackage(driver, xog.NewPackage(name, versionName, partitionModel, partition, splitList(replaces)), "packages/")
+}
+
+func createPackage(driver *model.Driver, pkg *model.Package, outputFolder string) bool {
+	zipPath, err := xog.CreatePackage(driver, pkg, constant.FolderWrite, outputFolder)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[PACKAGE]]: %s\n", err.Error())
+		return false
+	}
+	log.Info("\n[CAS-XOG][green[Package created]]: %s | Total files: [green[%d]]\n", zipPath, len(driver.Files))
+	return true
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != constant.Undefined {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func packageCommand(args []string, version string) int {
+	replaces := listFlag{}
+	variables := keyValueFlag{}
+
+	flags := flag.NewFlagSet("package", flag.ContinueOnError)
+	driverPath := flags.String("driver", constant.Undefined, "path to the driver file executed with action r")
+	name := flags.String("name", constant.Undefined, "name of the package, default is the driver file name")
+	versionName := flags.String("version", "Default", "name of the package version")
+	partitionModel := flags.String("partition-model", constant.Undefined, "default value of the target partition model definition")
+	partition := flags.String("partition", constant.Undefined, "default value of the target partition definition")
+	output := flags.String("output", "packages/", "folder to save the package zip")
+	flags.Var(&replaces, "replace", "string replaced by the value defined during the install, can be repeated")
+	flags.Var(variables, "var", "driver variable value as name=value, overrides the driver and environment values, can be repeated")
+	addLogFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return constant.ExitCodeUsage
+	}
+	if *driverPath == constant.Undefined {
+		fmt.Fprintf(os.Stderr, "[CAS-XOG]Error: command package requires the flag --driver\n")
+		flags.Usage()
+		return constant.ExitCodeUsage
+	}
+	if *name == constant.Undefined {
+		*name = strings.TrimSuffix(filepath.Base(*driverPath), filepath.Ext(*driverPath))
+	}
+
+	err := initialize(version)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+	xog.SetVariableOverrides(variables)
+
+	_, err = xog.LoadDriver(*driverPath)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return constant.ExitCodeUsage
+	}
+
+	if !createPackage(xog.GetLoadedDriver(), xog.NewPackage(*name, *versionName, *partitionModel, *partition, replaces), strings.TrimSuffix(*output, "/")+"/") {
+		return constant.ExitCodeError
+	}
+	return constant.ExitCodeSuccess
+}
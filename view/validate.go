@@ -0,0 +1,29 @@
+package view
+
+import (
+	"github.com/andreluzz/cas-xog/log"
+	"github.com/andreluzz/cas-xog/validate"
+)
+
+//selectedDriverPath stores the last driver chosen by the user, even when it could not be loaded, so it can be validated
+var selectedDriverPath string
+
+//validateDriver prints the issues found in the driver. Returns true if the driver has no issues
+func validateDriver(path string) bool {
+	issues, err := validate.Driver(path)
+	if err != nil {
+		log.Error("\n[CAS-XOG][red[ERROR]] - %s\n", err.Error())
+		return false
+	}
+
+	if len(issues) == 0 {
+		log.Info("\n[CAS-XOG][green[Driver valid]]: %s\n", path)
+		return true
+	}
+
+	log.Info("\n[CAS-XOG][red[Driver invalid]]: %s | Issues: [red[%d]]\n", path, len(issues))
+	for _, i := range issues {
+		log.Info("%s\n", i.String())
+	}
+	return false
+}
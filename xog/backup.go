@@ -0,0 +1,301 @@
+package xog
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/transform"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/validate"
+	"github.com/beevik/etree"
+)
+
+//BackupDriverName is the driver saved in the backup folder with the files to write back to the environment
+const BackupDriverName = "rollback.driver"
+
+//backupEnvironmentVar is the driver variable that stores the name of the environment backed up
+const backupEnvironmentVar = "backupEnvironment"
+
+//backupCreatedComment starts the comments of the rollback driver with the artifacts that did not exist before the write
+const backupCreatedComment = "created by the write, not removed by the rollback: "
+
+//activeBackup is used by ProcessDriverFile to save the target state before each write
+var activeBackup *Backup
+
+//Backup saves the state of the target environment before each file is written, so the write can be reverted with the action rollback
+type Backup struct {
+	Folder      string
+	Environment string
+	mutex       sync.Mutex
+	files       []model.DriverFile
+	created     []string
+	saved       map[string]bool
+}
+
+//NewBackup creates a backup in a timestamped folder inside _backup/ named after the driver and the environment
+func NewBackup(driverPath, envName string) *Backup {
+	name := strings.TrimSuffix(filepath.Base(driverPath), filepath.Ext(driverPath))
+	return &Backup{
+		Folder:      constant.FolderBackup + name + "_" + envName + "_" + time.Now().Format("20060102_150405") + "/",
+		Environment: envName,
+		saved:       map[string]bool{},
+	}
+}
+
+//SetBackup defines the backup used to save the target state before the writes. Use nil to disable it. Must not be called while the files are processed
+func SetBackup(b *Backup) {
+	activeBackup = b
+}
+
+//Save reads the file from the environment and saves it in the backup folder. The same read is saved only once, like the split files of an instance.
+//The response is transformed as in the read action. Returns false without error only if the artifact does not exist in the environment yet,
+//registering it as created by the write
+func (b *Backup) Save(file *model.DriverFile, env *model.EnvType, soapFunc util.Soap) (bool, error) {
+	if b == nil || file.RestAPI() || file.Type == constant.TypeMigration {
+		return false, nil
+	}
+
+	read := *file
+	if read.TargetPartition != constant.Undefined {
+		read.SourcePartition = read.TargetPartition
+	}
+	err := read.InitXML(constant.Read, constant.Undefined)
+	if err != nil {
+		return false, err
+	}
+
+	key := read.GetXML()
+	b.mutex.Lock()
+	saved := b.saved[key]
+	b.mutex.Unlock()
+	if saved {
+		return false, nil
+	}
+
+	err = read.RunXogXML(env, soapFunc)
+	if err != nil {
+		return false, err
+	}
+	xogResponse := etree.NewDocument()
+	err = xogResponse.ReadFromString(read.GetXML())
+	if err != nil {
+		return false, errors.New("backup read - " + err.Error())
+	}
+	output, err := validate.Check(xogResponse)
+	if err != nil {
+		if notFound(xogResponse, output) {
+			b.register(key, func() { b.created = append(b.created, describeBackupFile(file)) })
+			return false, nil
+		}
+		return false, errors.New("backup read - " + err.Error())
+	}
+
+	err = normalizeBackup(&read, xogResponse, env, soapFunc)
+	if err != nil {
+		return false, err
+	}
+
+	util.ValidateFolder(b.Folder + read.Type + util.GetPathFolder(read.Path))
+	read.Write(b.Folder)
+	return b.register(key, func() { b.files = append(b.files, read) }), nil
+}
+
+//register marks the read as saved only after it succeeds, so a failed read is tried again by the next write of the same artifact
+func (b *Backup) register(key string, add func()) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.saved[key] {
+		return false
+	}
+	b.saved[key] = true
+	add()
+	return true
+}
+
+//describeBackupFile identifies the artifact in the rollback driver and in the warnings of the rollback
+func describeBackupFile(file *model.DriverFile) string {
+	description := file.GetXMLType() + " code=" + file.Code
+	if file.ObjCode != constant.Undefined {
+		description += " objectCode=" + file.ObjCode
+	}
+	return description + " path=" + file.Path
+}
+
+//notFound checks if the read response has no records and no error, returned when the artifact does not exist in the environment
+func notFound(xogResponse *etree.Document, output model.Output) bool {
+	return xogResponse.FindElement("//ErrorInformation") == nil && output.Statistics != nil && output.Statistics.TotalNumberOfRecords == 0
+}
+
+//normalizeBackup transforms the read response as the read action does, so the saved file can be written back to the environment.
+//The replaces of the driver file are not applied, keeping the state of the environment
+func normalizeBackup(read *model.DriverFile, xogResponse *etree.Document, env *model.EnvType, soapFunc util.Soap) error {
+	var auxResponse *etree.Document
+	if read.NeedAuxXML() {
+		err := read.RunAuxXML(env, soapFunc)
+		if err != nil {
+			return err
+		}
+		auxResponse = etree.NewDocument()
+		err = auxResponse.ReadFromString(read.GetAuxXML())
+		if err != nil {
+			return errors.New("backup aux read - " + err.Error())
+		}
+		_, err = validate.Check(auxResponse)
+		if err != nil {
+			return errors.New("backup aux validation - " + err.Error())
+		}
+	}
+
+	read.Replace = nil
+	read.ExportToExcel = false
+	err := transform.Execute(xogResponse, auxResponse, read)
+	if err != nil {
+		return errors.New("backup transform - " + err.Error())
+	}
+
+	str, _ := xogResponse.WriteToString()
+	read.SetXML(str)
+	iniTagRegexpStr, endTagRegexpStr := read.TagCDATA()
+	if iniTagRegexpStr != constant.Undefined && endTagRegexpStr != constant.Undefined {
+		read.SetXML(transform.IncludeCDATA(read.GetXML(), iniTagRegexpStr, endTagRegexpStr))
+	}
+	return nil
+}
+
+//Total returns the number of files saved in the backup
+func (b *Backup) Total() int {
+	if b == nil {
+		return 0
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.files)
+}
+
+//Created returns the artifacts that did not exist in the environment before the write
+func (b *Backup) Created() []string {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]string{}, b.created...)
+}
+
+//Close creates the rollback driver with the files saved in the backup, in the same order they were written, with all the attributes used to read them.
+//The attribute dependsOn is not kept, the files are already in the order of the write. The artifacts created by the write are saved as comments
+func (b *Backup) Close() error {
+	if b == nil || (len(b.files) == 0 && len(b.created) == 0) {
+		return nil
+	}
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="utf-8"`)
+	root := doc.CreateElement("xogdriver")
+	root.CreateAttr("version", fmt.Sprintf("%.1f", constant.Version))
+	vars := root.CreateElement("vars")
+	v := vars.CreateElement("var")
+	v.CreateAttr("name", backupEnvironmentVar)
+	v.CreateAttr("value", b.Environment)
+
+	for _, c := range b.created {
+		root.CreateComment(" " + backupCreatedComment + c + " ")
+	}
+	for _, f := range b.files {
+		f.DependsOn = constant.Undefined
+		e := root.CreateElement(f.GetXMLType())
+		writeDriverFields(e, reflect.ValueOf(f))
+	}
+
+	doc.Indent(4)
+	return doc.WriteToFile(b.Folder + BackupDriverName)
+}
+
+var driverFileType = reflect.TypeOf(model.DriverFile{})
+
+//writeDriverFields creates the attributes and sub tags of the driver entry from the fields with xml tags that are defined.
+//The attribute type is not written because it is defined by the name of the entry
+func writeDriverFields(e *etree.Element, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("xml")
+		if !ok || tag == "-" || t.Field(i).PkgPath != constant.Undefined || (t == driverFileType && tag == "type,attr") {
+			continue
+		}
+		field := v.Field(i)
+		name := strings.Split(tag, ",")[0]
+		switch {
+		case strings.HasSuffix(tag, ",attr"):
+			if !field.IsZero() {
+				e.CreateAttr(name, fmt.Sprint(field.Interface()))
+			}
+		case strings.HasSuffix(tag, ",chardata"):
+			e.SetText(field.String())
+		case field.Kind() == reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				writeDriverFields(e.CreateElement(name), field.Index(j))
+			}
+		case field.Kind() == reflect.String && field.String() != constant.Undefined:
+			e.CreateElement(name).SetText(field.String())
+		}
+	}
+}
+
+//GetBackupsList returns the backup folders with a rollback driver, the most recent first
+func GetBackupsList(folder string) ([]string, error) {
+	folders, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil, errors.New("no backups found in folder " + folder)
+	}
+	sort.SliceStable(folders, func(i, j int) bool {
+		return folders[i].ModTime().After(folders[j].ModTime())
+	})
+	backups := []string{}
+	for _, f := range folders {
+		if !f.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(folder + f.Name() + "/" + BackupDriverName); err == nil {
+			backups = append(backups, folder+f.Name()+"/")
+		}
+	}
+	if len(backups) == 0 {
+		return nil, errors.New("no backups found in folder " + folder)
+	}
+	return backups, nil
+}
+
+//LoadBackup loads the rollback driver of the backup folder and returns the total of files, the name of the environment backed up
+//and the artifacts created by the write, that cannot be removed by the rollback
+func LoadBackup(folder string) (int, string, []string, error) {
+	path := strings.TrimSuffix(folder, "/") + "/" + BackupDriverName
+	total, err := LoadDriver(path)
+	if err != nil {
+		return 0, constant.Undefined, nil, err
+	}
+	doc := etree.NewDocument()
+	doc.ReadFromBytes(driverXOG.XML)
+	envName := constant.Undefined
+	if v := doc.FindElement("//xogdriver/vars/var[@name='" + backupEnvironmentVar + "']"); v != nil {
+		envName = v.SelectAttrValue("value", constant.Undefined)
+	}
+	created := []string{}
+	if doc.Root() != nil {
+		for _, token := range doc.Root().Child {
+			if c, ok := token.(*etree.Comment); ok && strings.HasPrefix(strings.TrimSpace(c.Data), backupCreatedComment) {
+				created = append(created, strings.TrimPrefix(strings.TrimSpace(c.Data), backupCreatedComment))
+			}
+		}
+	}
+	return total, envName, created, nil
+}
@@ -0,0 +1,222 @@
+package xog
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+func backupSoapMock(request, endpoint, proxy string) (string, error) {
+	mock := "../mock/xog/soap/soap_success_write_response.xml"
+	if strings.Contains(request, "<LookupQuery>") {
+		mock = "../mock/xog/soap/soap_success_read_response.xml"
+	}
+	file, _ := ioutil.ReadFile(mock)
+	return util.BytesToString(file), nil
+}
+
+func loadBackupMockFile(t *testing.T) model.DriverFile {
+	LoadDriver("../mock/xog/xog.driver")
+	for _, f := range GetLoadedDriver().Files {
+		if f.Type == constant.TypeLookup && f.Code == "LOOKUP_CAS_XOG_1" {
+			return f
+		}
+	}
+	t.Fatalf("Error loading mock driver, lookup LOOKUP_CAS_XOG_1 not found")
+	return model.DriverFile{}
+}
+
+func TestProcessDriverFileWriteWithBackup(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll(constant.FolderBackup)
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+	defer os.RemoveAll(constant.FolderDebug)
+
+	file := loadBackupMockFile(t)
+	mockEnvironments := &model.Environments{
+		Target: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"},
+	}
+
+	b := NewBackup("../mock/xog/xog.driver", "DEV")
+	SetBackup(b)
+	defer SetBackup(nil)
+
+	util.ValidateFolder(constant.FolderDebug + file.Type)
+	output := ProcessDriverFile(&file, constant.Write, "../mock/xog/soap/", constant.FolderDebug, mockEnvironments, backupSoapMock)
+	if output.Code != constant.OutputSuccess {
+		t.Fatalf("Error processing driver file with backup. Debug: %s", output.Debug)
+	}
+	//the same artifact is saved only once
+	ProcessDriverFile(&file, constant.Write, "../mock/xog/soap/", constant.FolderDebug, mockEnvironments, backupSoapMock)
+	if b.Total() != 1 {
+		t.Fatalf("Error saving backup, expected 1 file received %d", b.Total())
+	}
+
+	saved, err := ioutil.ReadFile(b.Folder + file.Type + "/" + file.Path)
+	if err != nil || !strings.Contains(string(saved), "LOOKUP_CAS_XOG_1") {
+		t.Fatalf("Error saving backup, target state of the lookup not saved in %s", b.Folder)
+	}
+	if strings.Contains(string(saved), "XOGOutput") {
+		t.Errorf("Error saving backup, read response not transformed before saving")
+	}
+	if !strings.HasPrefix(b.Folder, constant.FolderBackup+"xog_DEV_") {
+		t.Errorf("Error creating backup, invalid folder %s", b.Folder)
+	}
+
+	err = b.Close()
+	if err != nil {
+		t.Fatalf("Error saving rollback driver. Debug: %s", err.Error())
+	}
+
+	backups, err := GetBackupsList(constant.FolderBackup)
+	if err != nil || len(backups) != 1 || backups[0] != b.Folder {
+		t.Fatalf("Error listing backups, expected %s received %v", b.Folder, backups)
+	}
+
+	total, envName, created, err := LoadBackup(b.Folder)
+	if err != nil {
+		t.Fatalf("Error loading backup. Debug: %s", err.Error())
+	}
+	if total != 1 || envName != "DEV" || len(created) != 0 {
+		t.Errorf("Error loading backup, expected 1 file from DEV received %d from %s", total, envName)
+	}
+
+	written := ""
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		if !strings.Contains(request, "<LookupQuery>") {
+			written = request
+		}
+		return backupSoapMock(request, endpoint, proxy)
+	}
+	rollback := GetLoadedDriver().Files[0]
+	output = ProcessDriverFile(&rollback, constant.Write, b.Folder, constant.FolderDebug, mockEnvironments, soapMock)
+	if output.Code != constant.OutputSuccess {
+		t.Errorf("Error writing backup back to the environment. Debug: %s", output.Debug)
+	}
+	if !strings.Contains(written, "LOOKUP_CAS_XOG_1") || strings.Contains(written, "XOGOutput") {
+		t.Errorf("Error writing backup back to the environment, invalid xog sent")
+	}
+}
+
+func TestProcessDriverFileWriteWithBackupError(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll(constant.FolderBackup)
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+
+	file := loadBackupMockFile(t)
+	mockEnvironments := &model.Environments{
+		Target: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"},
+	}
+
+	SetBackup(NewBackup("../mock/xog/xog.driver", "DEV"))
+	defer SetBackup(nil)
+
+	writes := 0
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		if strings.Contains(request, "<LookupQuery>") {
+			return "", &util.StatusError{StatusCode: 500, Body: "Internal Server Error"}
+		}
+		writes++
+		return backupSoapMock(request, endpoint, proxy)
+	}
+
+	output := ProcessDriverFile(&file, constant.Write, "../mock/xog/soap/", constant.FolderDebug, mockEnvironments, soapMock)
+	if output.Code != constant.OutputError || writes > 0 {
+		t.Errorf("Error processing driver file with backup, expected the write to be skipped when the backup fails")
+	}
+}
+
+func TestBackupSaveReadError(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll(constant.FolderBackup)
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+
+	file := loadBackupMockFile(t)
+	env := &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"}
+
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_read_process_no_output_response.xml")
+		return util.BytesToString(file), nil
+	}
+
+	b := NewBackup("../mock/xog/xog.driver", "DEV")
+	saved, err := b.Save(&file, env, soapMock)
+	if err == nil || saved || b.Total() != 0 {
+		t.Errorf("Error saving backup, not catching error with invalid read response")
+	}
+	//the failed read is not marked as saved, the next write of the artifact tries again
+	saved, err = b.Save(&file, env, backupSoapMock)
+	if err != nil || !saved || b.Total() != 1 {
+		t.Errorf("Error saving backup, artifact not saved after a failed read")
+	}
+
+	notFoundMock := func(request, endpoint, proxy string) (string, error) {
+		return `<NikuDataBus><XOGOutput><Status state="SUCCESS"/><Statistics totalNumberOfRecords="0" failureRecords="0"/></XOGOutput></NikuDataBus>`, nil
+	}
+	b = NewBackup("../mock/xog/xog.driver", "DEV")
+	saved, err = b.Save(&file, env, notFoundMock)
+	if err != nil || saved {
+		t.Errorf("Error saving backup, artifact not found in the environment should be skipped without error")
+	}
+	if err = b.Close(); err != nil {
+		t.Fatalf("Error saving rollback driver. Debug: %s", err.Error())
+	}
+	total, _, created, err := LoadBackup(b.Folder)
+	if err != nil || total != 0 || len(created) != 1 || created[0] != "lookup code=LOOKUP_CAS_XOG_1 path="+file.Path {
+		t.Errorf("Error loading backup, artifact created by the write not registered. Received %v", created)
+	}
+}
+
+func TestBackupCloseToKeepDriverAttributes(t *testing.T) {
+	defer os.RemoveAll(constant.FolderBackup)
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+
+	files := []model.DriverFile{
+		{
+			Type: constant.TypeResourceInstance, Code: "*", Path: "res_001.xml", InstanceTag: "Resource", InstancesPerFile: 50,
+			Filters: []model.Filter{{Name: "resourceID", Criteria: "BETWEEN", Value: "a,z"}},
+		},
+		{
+			Type: constant.TypeView, Code: "projectList", ObjCode: "project", Path: "view.xml", SourcePartition: "NIKU.ROOT", TargetPartition: "NIKU.ROOT",
+			Sections: []model.Section{{Code: "general", Action: constant.ActionUpdate, Fields: []model.SectionField{{Code: "name", Column: "left"}}}},
+		},
+		{
+			Type: constant.TypeObject, Code: "project", Path: "obj.xml", PartitionModel: "model", OnlyStructure: true,
+			Elements: []model.Element{{Type: "attribute", Code: "status", XMLString: "<a/>"}},
+		},
+	}
+	b := NewBackup("rollback_attributes.driver", "DEV")
+	b.files = files
+	util.ValidateFolder(b.Folder)
+	if err := b.Close(); err != nil {
+		t.Fatalf("Error saving rollback driver. Debug: %s", err.Error())
+	}
+
+	total, _, _, err := LoadBackup(b.Folder)
+	if err != nil || total != len(files) {
+		t.Fatalf("Error loading rollback driver. Debug: %v", err)
+	}
+	for i, f := range GetLoadedDriver().Files {
+		f.ExecutionOrder = 0
+		if !reflect.DeepEqual(f, files[i]) {
+			t.Errorf("Error saving rollback driver, attributes of %s not kept. Expected %+v received %+v", files[i].Path, files[i], f)
+		}
+	}
+}
+
+func TestGetBackupsListEmpty(t *testing.T) {
+	_, err := GetBackupsList("../mock/xog/not_found/")
+	if err == nil {
+		t.Errorf("Error listing backups, not catching error with invalid folder")
+	}
+}
@@ -0,0 +1,61 @@
+package xog
+
+import (
+	"errors"
+
+	"github.com/andreluzz/cas-xog/compare"
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/beevik/etree"
+)
+
+//CompareDriverFile reads the driver file from the source and the target environments and compares both xog after the transformations.
+//The normalized xog of each environment is saved in the folder _compare/<environment name>/
+func CompareDriverFile(file *model.DriverFile, environments *model.Environments, soapFunc util.Soap) *compare.Artifact {
+	artifact := &compare.Artifact{Type: file.GetXMLType(), Code: file.Code, Path: file.Path, Changes: []compare.Change{}}
+
+	if file.RestAPI() || file.Type == constant.TypeMigration {
+		artifact.Status = compare.StatusIgnored
+		artifact.Debug = "compare not available for tag <" + file.GetXMLType() + ">"
+		return artifact
+	}
+
+	docs := []*etree.Document{}
+	for _, env := range []*model.EnvType{environments.Source, environments.Target} {
+		doc, err := readToCompare(*file, env, soapFunc)
+		if err != nil {
+			artifact.Status = compare.StatusError
+			artifact.Debug = env.Name + " - " + err.Error()
+			return artifact
+		}
+		docs = append(docs, doc)
+	}
+
+	artifact.SetChanges(compare.Diff(docs[0], docs[1]))
+	return artifact
+}
+
+//readToCompare executes the read of a copy of the file using the environment as source and target, so the transformations do not mix both environments
+func readToCompare(file model.DriverFile, env *model.EnvType, soapFunc util.Soap) (*etree.Document, error) {
+	file.InstancesPerFile = 0
+	file.ExportToExcel = false
+	//the transformations can change the slices of the file, like the wildcard elements of an object
+	file.Elements = append([]model.Element{}, file.Elements...)
+	file.Sections = append([]model.Section{}, file.Sections...)
+
+	folder := constant.FolderCompare + env.Name + "/"
+	util.ValidateFolder(folder + file.Type + util.GetPathFolder(file.Path))
+
+	output := ProcessDriverFile(&file, constant.Read, folder, folder, &model.Environments{Source: env, Target: env}, soapFunc)
+	if output.Code == constant.OutputError {
+		return nil, errors.New(output.Debug)
+	}
+
+	doc := etree.NewDocument()
+	err := doc.ReadFromString(file.GetXML())
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
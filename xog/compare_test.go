@@ -0,0 +1,74 @@
+package xog
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/compare"
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+func compareMockEnvironments() *model.Environments {
+	return &model.Environments{
+		Source: &model.EnvType{Name: "DEV", URL: "http://dev", Session: "Mock session"},
+		Target: &model.EnvType{Name: "PROD", URL: "http://prod", Session: "Mock session"},
+	}
+}
+
+func TestCompareDriverFile(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll(constant.FolderCompare)
+
+	file := model.DriverFile{Type: constant.TypeLookup, Code: "LOOKUP_CAS_XOG", Path: "lookup.xml"}
+
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		mock := "../mock/compare/lookup_target.xml"
+		if strings.HasPrefix(endpoint, "http://dev") {
+			mock = "../mock/compare/lookup_source.xml"
+		}
+		file, _ := ioutil.ReadFile(mock)
+		return util.BytesToString(file), nil
+	}
+
+	artifact := CompareDriverFile(&file, compareMockEnvironments(), soapMock)
+	if artifact.Status != compare.StatusDifferent {
+		t.Fatalf("Error comparing driver file. Expected status %s and received %s. Debug: %s", compare.StatusDifferent, artifact.Status, artifact.Debug)
+	}
+	if len(artifact.Changes) != 3 {
+		t.Errorf("Error comparing driver file. Expected 3 changes and received %d: %v", len(artifact.Changes), artifact.Changes)
+	}
+	if _, err := os.Stat(constant.FolderCompare + "PROD/" + constant.TypeLookup + "/lookup.xml"); err != nil {
+		t.Errorf("Error comparing driver file. Normalized xog not saved. Debug: %s", err.Error())
+	}
+}
+
+func TestCompareDriverFileWithError(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll(constant.FolderCompare)
+	defer os.RemoveAll(constant.FolderDebug)
+
+	file := model.DriverFile{Type: constant.TypeLookup, Code: "LOOKUP_CAS_XOG", Path: "lookup.xml"}
+
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		return "", errors.New("connection refused")
+	}
+
+	artifact := CompareDriverFile(&file, compareMockEnvironments(), soapMock)
+	if artifact.Status != compare.StatusError || !strings.HasPrefix(artifact.Debug, "DEV - ") {
+		t.Errorf("Error comparing driver file. Expected status %s from environment DEV and received %s. Debug: %s", compare.StatusError, artifact.Status, artifact.Debug)
+	}
+}
+
+func TestCompareDriverFileIgnoreMigration(t *testing.T) {
+	file := model.DriverFile{Type: constant.TypeMigration, Path: "migration.xml"}
+
+	artifact := CompareDriverFile(&file, compareMockEnvironments(), nil)
+	if artifact.Status != compare.StatusIgnored {
+		t.Errorf("Error comparing driver file. Expected status %s and received %s", compare.StatusIgnored, artifact.Status)
+	}
+}
@@ -0,0 +1,220 @@
+package xog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/validate"
+	"github.com/beevik/etree"
+)
+
+//standardSource is the source of the artifacts delivered with the product, that already exist in every environment
+const standardSource = "niku.com"
+
+//discoveryOrder defines the order of the types in the driver created, so each artifact is written after its dependencies
+var discoveryOrder = []string{constant.TypeLookup, constant.TypeObject, constant.TypeView, constant.TypeProcess, constant.TypePortlet, constant.TypePage}
+
+//Dependency defines an artifact found by the discovery and the reason it is needed
+type Dependency struct {
+	Type       string
+	Code       string
+	ObjectCode string
+	Reason     string
+}
+
+//Discovery reads an object and walks its references to find the artifacts needed to move it to another environment
+type Discovery struct {
+	Env             *model.EnvType
+	Soap            util.Soap
+	IncludeStandard bool
+	dependencies    []Dependency
+	found           map[string]bool
+}
+
+//DiscoverObject reads the object xog and returns the lookups used by its attributes, the object and its sub-objects, the views of each one,
+//the processes started by its actions and the custom pages, with their portlets, opened by its links
+func (d *Discovery) DiscoverObject(objectCode string) ([]Dependency, error) {
+	d.dependencies = []Dependency{}
+	d.found = map[string]bool{}
+
+	object, err := d.read(&model.DriverFile{Type: constant.TypeObject, Code: objectCode, Path: objectCode + ".xml"})
+	if err != nil {
+		return nil, fmt.Errorf("error reading object %s - %s", objectCode, err.Error())
+	}
+	main := object.FindElement("//objects/object[@code='" + objectCode + "']")
+	if main == nil {
+		return nil, fmt.Errorf("object %s not found in environment %s", objectCode, d.Env.Name)
+	}
+	d.add(Dependency{Type: constant.TypeObject, Code: objectCode, Reason: "object discovered"})
+	d.addSubObjects(object, objectCode)
+
+	for _, o := range object.FindElements("//objects/object") {
+		code := o.SelectAttrValue("code", constant.Undefined)
+		if parent := o.SelectAttrValue("parentObjectCode", constant.Undefined); parent != constant.Undefined {
+			d.add(Dependency{Type: constant.TypeView, Code: "*", ObjectCode: code, Reason: "views of sub-object " + code + " from " + parent})
+		} else {
+			d.add(Dependency{Type: constant.TypeView, Code: "*", ObjectCode: code, Reason: "views of object " + code})
+		}
+
+		for _, a := range o.FindElements(".//customAttribute[@lookupType]") {
+			lookup := a.SelectAttrValue("lookupType", constant.Undefined)
+			if lookup == constant.Undefined || d.found[constant.TypeLookup+"|"+lookup] {
+				continue
+			}
+			d.found[constant.TypeLookup+"|"+lookup] = true
+			standard, err := d.standard(&model.DriverFile{Type: constant.TypeLookup, Code: lookup, Path: lookup + ".xml"}, "//lookups/*")
+			if err != nil {
+				return nil, fmt.Errorf("error reading lookup %s - %s", lookup, err.Error())
+			}
+			if !standard {
+				d.add(Dependency{Type: constant.TypeLookup, Code: lookup, Reason: "lookup used by attribute " + code + "." + a.SelectAttrValue("code", constant.Undefined)})
+			}
+		}
+
+		for _, a := range o.FindElements(".//actions/action") {
+			process := a.SelectAttrValue("processCode", "NONE")
+			if process != "NONE" && process != constant.Undefined {
+				d.add(Dependency{Type: constant.TypeProcess, Code: process, Reason: "process started by action " + a.SelectAttrValue("code", constant.Undefined)})
+			}
+		}
+
+		for _, l := range o.FindElements(".//links/link") {
+			if l.SelectAttrValue("system", "false") == "true" {
+				continue
+			}
+			err := d.discoverPage(l.SelectAttrValue("action", constant.Undefined), "page opened by link "+l.SelectAttrValue("code", constant.Undefined))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.SliceStable(d.dependencies, func(i, j int) bool {
+		return typeOrder(d.dependencies[i].Type) < typeOrder(d.dependencies[j].Type)
+	})
+	return d.dependencies, nil
+}
+
+//addSubObjects includes the sub-objects of the parent, each one after its parent and before its own sub-objects
+func (d *Discovery) addSubObjects(object *etree.Document, parent string) {
+	for _, o := range object.FindElements("//objects/object[@parentObjectCode='" + parent + "']") {
+		code := o.SelectAttrValue("code", constant.Undefined)
+		if d.found[constant.TypeObject+"|"+code+"|"] {
+			continue
+		}
+		d.add(Dependency{Type: constant.TypeObject, Code: code, Reason: "sub-object " + code + " from " + parent})
+		d.addSubObjects(object, code)
+	}
+}
+
+//discoverPage includes the page opened by a link, if it is a custom page, and its custom portlets before it
+func (d *Discovery) discoverPage(code, reason string) error {
+	if code == constant.Undefined || d.found[constant.TypePage+"|"+code] {
+		return nil
+	}
+	d.found[constant.TypePage+"|"+code] = true
+	page, err := d.read(&model.DriverFile{Type: constant.TypePage, Code: code, Path: code + ".xml"})
+	if err != nil {
+		return fmt.Errorf("error reading page %s - %s", code, err.Error())
+	}
+	pageElement := page.FindElement("//pages/*")
+	if pageElement == nil || (!d.IncludeStandard && pageElement.SelectAttrValue("source", constant.Undefined) == standardSource) {
+		return nil
+	}
+	for _, p := range pageElement.FindElements(".//portlet") {
+		if !d.IncludeStandard && p.SelectAttrValue("source", constant.Undefined) == standardSource {
+			continue
+		}
+		d.add(Dependency{Type: constant.TypePortlet, Code: p.SelectAttrValue("code", constant.Undefined), Reason: "portlet of page " + code})
+	}
+	d.add(Dependency{Type: constant.TypePage, Code: code, Reason: reason})
+	return nil
+}
+
+//standard reads the artifact and validates if it was delivered with the product
+func (d *Discovery) standard(file *model.DriverFile, path string) (bool, error) {
+	if d.IncludeStandard {
+		return false, nil
+	}
+	doc, err := d.read(file)
+	if err != nil {
+		return false, err
+	}
+	element := doc.FindElement(path)
+	return element != nil && element.SelectAttrValue("source", constant.Undefined) == standardSource, nil
+}
+
+func (d *Discovery) read(file *model.DriverFile) (*etree.Document, error) {
+	err := file.InitXML(constant.Read, constant.Undefined)
+	if err != nil {
+		return nil, err
+	}
+	err = file.RunXogXML(d.Env, d.Soap)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	err = doc.ReadFromString(file.GetXML())
+	if err != nil {
+		return nil, err
+	}
+	_, err = validate.Check(doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (d *Discovery) add(dependency Dependency) {
+	key := dependency.Type + "|" + dependency.Code + "|" + dependency.ObjectCode
+	if d.found[key] {
+		return
+	}
+	d.found[key] = true
+	d.dependencies = append(d.dependencies, dependency)
+}
+
+func typeOrder(fileType string) int {
+	for i, t := range discoveryOrder {
+		if t == fileType {
+			return i
+		}
+	}
+	return len(discoveryOrder)
+}
+
+//SaveDiscoveredDriver creates a driver with the dependencies, each one preceded by a comment with the reason it was included
+func SaveDiscoveredDriver(path, objectCode, envName string, dependencies []Dependency) error {
+	if len(dependencies) == 0 {
+		return errors.New("no dependencies to save")
+	}
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="utf-8"`)
+	root := doc.CreateElement("xogdriver")
+	root.CreateAttr("version", fmt.Sprintf("%.1f", constant.Version))
+	root.CreateComment(fmt.Sprintf(" Discovered from object %s in environment %s at %s ", objectCode, envName, time.Now().Format("2006-01-02 15:04:05")))
+
+	for _, dep := range dependencies {
+		file := model.DriverFile{Type: dep.Type}
+		root.CreateComment(" " + dep.Reason + " ")
+		e := root.CreateElement(file.GetXMLType())
+		e.CreateAttr("code", dep.Code)
+		filePath := dep.Code + ".xml"
+		if dep.Type == constant.TypeView {
+			e.CreateAttr("objectCode", dep.ObjectCode)
+			filePath = dep.ObjectCode + "_views.xml"
+		}
+		e.CreateAttr("path", filePath)
+	}
+
+	doc.Indent(4)
+	util.ValidateFolder(filepath.Dir(path))
+	return doc.WriteToFile(path)
+}
@@ -0,0 +1,120 @@
+package xog
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/validate"
+)
+
+func discoverSoapMock(request, endpoint, proxy string) (string, error) {
+	mock := "object.xml"
+	switch {
+	case strings.Contains(request, "<LookupQuery>") && strings.Contains(request, "CAL_ACTIONITEM_STATUS"):
+		mock = "lookup_standard.xml"
+	case strings.Contains(request, "<LookupQuery>"):
+		mock = "lookup_custom.xml"
+	case strings.Contains(request, "<PageQuery>") && strings.Contains(request, "obj_sistema.dashboard"):
+		mock = "page_custom.xml"
+	case strings.Contains(request, "<PageQuery>"):
+		mock = "page_standard.xml"
+	}
+	file, _ := ioutil.ReadFile("../mock/discover/" + mock)
+	return util.BytesToString(file), nil
+}
+
+func TestDiscoverObject(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	d := &Discovery{Env: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"}, Soap: discoverSoapMock}
+	dependencies, err := d.DiscoverObject("obj_sistema")
+	if err != nil {
+		t.Fatalf("Error discovering object dependencies. Debug: %s", err.Error())
+	}
+
+	expected := []string{
+		"Lookups|OBJ_SISTEMA_STATUS|",
+		"Lookups|OBJ_SUB_TYPE|",
+		"Objects|obj_sistema|",
+		"Objects|obj_sub_sistema|",
+		"Views|*|obj_sistema",
+		"Views|*|obj_sub_sistema",
+		"Processes|prc_obj_sistema_approve|",
+		"Portlets|obj_sistema_grid|",
+		"Pages|obj_sistema.dashboard|",
+	}
+	if len(dependencies) != len(expected) {
+		t.Fatalf("Error discovering object dependencies. Expected %d dependencies and received %d: %v", len(expected), len(dependencies), dependencies)
+	}
+	for i, e := range expected {
+		dep := dependencies[i]
+		if dep.Type+"|"+dep.Code+"|"+dep.ObjectCode != e {
+			t.Errorf("Error discovering object dependencies. Expected %s at position %d and received %v", e, i, dep)
+		}
+	}
+}
+
+func TestDiscoverObjectIncludeStandard(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	d := &Discovery{Env: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"}, Soap: discoverSoapMock, IncludeStandard: true}
+	dependencies, err := d.DiscoverObject("obj_sistema")
+	if err != nil {
+		t.Fatalf("Error discovering object dependencies. Debug: %s", err.Error())
+	}
+
+	codes := []string{}
+	for _, dep := range dependencies {
+		codes = append(codes, dep.Code)
+	}
+	list := strings.Join(codes, ",")
+	for _, code := range []string{"CAL_ACTIONITEM_STATUS", "projmgr.teamUtilization", "pma.ideaProperties"} {
+		if !strings.Contains(list, code) {
+			t.Errorf("Error discovering object dependencies. Expected standard artifact %s and received %s", code, list)
+		}
+	}
+}
+
+func TestDiscoverObjectNotFound(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	d := &Discovery{Env: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"}, Soap: discoverSoapMock}
+	_, err := d.DiscoverObject("obj_not_found")
+	if err == nil {
+		t.Errorf("Error discovering object dependencies. Not catching error with object not found")
+	}
+}
+
+func TestSaveDiscoveredDriver(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll("../" + constant.FolderDebug + "discover")
+	//keeps the loaded driver of the other tests
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+
+	d := &Discovery{Env: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"}, Soap: discoverSoapMock}
+	dependencies, _ := d.DiscoverObject("obj_sistema")
+
+	path := "../" + constant.FolderDebug + "discover/obj_sistema.driver"
+	err := SaveDiscoveredDriver(path, "obj_sistema", "DEV", dependencies)
+	if err != nil {
+		t.Fatalf("Error saving discovered driver. Debug: %s", err.Error())
+	}
+
+	total, err := LoadDriver(path)
+	if err != nil || total != len(dependencies) {
+		t.Fatalf("Error loading discovered driver. Expected %d files and received %d. Debug: %v", len(dependencies), total, err)
+	}
+	issues, _ := validate.Driver(path)
+	if len(issues) > 0 {
+		t.Errorf("Error validating discovered driver. Issues: %v", issues)
+	}
+	if GetLoadedDriver().Files[0].Type != constant.TypeLookup || GetLoadedDriver().Files[total-1].Type != constant.TypePage {
+		t.Errorf("Error loading discovered driver. Expected lookups first and pages last")
+	}
+}
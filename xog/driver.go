@@ -26,6 +26,10 @@ var driverXOG *model.Driver
 
 //LoadDriver load an specific driver defined by a path
 func LoadDriver(path string) (int, error) {
+	return loadDriver(path, nil)
+}
+
+func loadDriver(path string, env *model.EnvType) (int, error) {
 	driverXOG = &model.Driver{}
 	driverXOG.Clear()
 	xmlFile, err := ioutil.ReadFile(path)
@@ -33,8 +37,21 @@ func LoadDriver(path string) (int, error) {
 		return 0, errors.New("Error loading driver file - " + err.Error())
 	}
 
+	xmlFile, err = includeDrivers(path, xmlFile)
+	if err != nil {
+		return 0, err
+	}
+
+	xmlFile, err = replaceVariables(path, xmlFile, env)
+	if err != nil {
+		return 0, err
+	}
+
 	driverXOGTypePattern := model.DriverTypesPattern{}
-	xml.Unmarshal(xmlFile, &driverXOGTypePattern)
+	err = xml.Unmarshal(xmlFile, &driverXOGTypePattern)
+	if err != nil {
+		return 0, fmt.Errorf("invalid driver(%s) - %s", path, err.Error())
+	}
 
 	v, err := strconv.ParseFloat(driverXOGTypePattern.Version, 64)
 	if err != nil || v < constant.Version {
@@ -65,13 +82,20 @@ func LoadDriver(path string) (int, error) {
 		return 0, fmt.Errorf("invalid driver(%s) tag <%s> is incorrect", path, doc.Root().Tag)
 	}
 
+	barrier := false
 	for i, e := range doc.FindElements("//xogdriver/*") {
 		tag := e.Tag
+		if tag == "barrier" {
+			barrier = true
+			continue
+		}
 		path := e.SelectAttrValue("path", constant.Undefined)
 		code := e.SelectAttrValue("code", constant.Undefined)
 		for y, f := range driverXOG.Files {
 			if f.ExecutionOrder == -1 && (strings.ToLower(f.GetXMLType()) == strings.ToLower(tag)) && (f.Path == path) && (f.Code == code) {
 				driverXOG.Files[y].ExecutionOrder = i
+				driverXOG.Files[y].Barrier = barrier
+				barrier = false
 				break
 			}
 		}
@@ -79,13 +103,108 @@ func LoadDriver(path string) (int, error) {
 
 	sort.Sort(model.ByExecutionOrder(driverXOG.Files))
 
+	for i, f := range driverXOG.Files {
+		if f.DependsOn != constant.Undefined && !dependencyDefinedBefore(driverXOG.Files[:i], f.DependsOn) {
+			return 0, fmt.Errorf("invalid driver(%s) file %s depends on %s that is not defined before it", path, f.Path, f.DependsOn)
+		}
+	}
+
 	driverXOG.Version = driverXOGTypePattern.Version
 	driverXOG.AutomaticWrite = driverXOGTypePattern.AutomaticWrite
+	driverXOG.Concurrency = driverXOGTypePattern.Concurrency
+	driverXOG.Backup = driverXOGTypePattern.Backup
 	driverXOG.FilePath = path
+	driverXOG.XML = xmlFile
 
 	return len(driverXOG.Files), nil
 }
 
+//includeDrivers replaces the tags <include path="..."/> with the entries of the included drivers, keeping their position in the execution order
+func includeDrivers(path string, xmlFile []byte) ([]byte, error) {
+	doc := etree.NewDocument()
+	if doc.ReadFromBytes(xmlFile) != nil || doc.Root() == nil || len(doc.FindElements("//xogdriver/include")) == 0 {
+		return xmlFile, nil
+	}
+
+	err := expandIncludes(doc, path, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return doc.WriteToBytes()
+}
+
+func expandIncludes(doc *etree.Document, path string, chain []string) error {
+	absPath, _ := filepath.Abs(path)
+	for i, p := range chain {
+		if p == absPath {
+			cycle := []string{}
+			for _, c := range chain[i:] {
+				cycle = append(cycle, filepath.ToSlash(relativePath(c)))
+			}
+			cycle = append(cycle, filepath.ToSlash(relativePath(absPath)))
+			return fmt.Errorf("invalid driver(%s) include cycle: %s", path, strings.Join(cycle, " -> "))
+		}
+	}
+	chain = append(chain, absPath)
+
+	root := doc.Root()
+	if root.Tag != "xogdriver" {
+		return fmt.Errorf("invalid driver(%s) tag <%s> is incorrect", path, root.Tag)
+	}
+
+	for _, e := range root.SelectElements("include") {
+		includePath := e.SelectAttrValue("path", constant.Undefined)
+		if includePath == constant.Undefined {
+			return fmt.Errorf("invalid driver(%s) tag <include> without attribute path", path)
+		}
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+
+		included := etree.NewDocument()
+		err := included.ReadFromFile(includePath)
+		if err != nil {
+			return fmt.Errorf("invalid driver(%s) error loading included driver %s - %s", path, includePath, err.Error())
+		}
+		if included.Root() == nil {
+			return fmt.Errorf("invalid driver(%s) included driver %s is empty", path, includePath)
+		}
+		err = expandIncludes(included, includePath, chain)
+		if err != nil {
+			return err
+		}
+
+		index := e.Index()
+		for _, c := range included.Root().ChildElements() {
+			root.InsertChildAt(index, c.Copy())
+			index++
+		}
+		root.RemoveChild(e)
+	}
+	return nil
+}
+
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+func dependencyDefinedBefore(files []model.DriverFile, path string) bool {
+	for _, f := range files {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
 //GetLoadedDriver returns the pointer to the loaded driver
 func GetLoadedDriver() *model.Driver {
 	return driverXOG
@@ -99,6 +218,19 @@ func ValidateLoadedDriver() bool {
 	return len(driverXOG.Files) > 0
 }
 
+//MigrationNeedsTarget returns true if any migration of the loaded driver validates the data against an object of the target environment
+func MigrationNeedsTarget() bool {
+	if driverXOG == nil {
+		return false
+	}
+	for _, f := range driverXOG.Files {
+		if f.Type == constant.TypeMigration && f.ValidateObject != constant.Undefined {
+			return true
+		}
+	}
+	return false
+}
+
 //GetDriversList returns a list of available drivers in the defined folder
 func GetDriversList(folder string) ([]model.Driver, error) {
 	var driversList []model.Driver
@@ -153,7 +285,16 @@ func ProcessDriverFile(file *model.DriverFile, action, sourceFolder, outputFolde
 	}
 
 	if action == constant.Migrate {
-		return processMigrate(file, outputFolder)
+		return processMigrate(file, outputFolder, environments, soapFunc)
+	}
+
+	if action == constant.Write && activeBackup != nil {
+		_, err := activeBackup.Save(file, environments.Target, soapFunc)
+		if err != nil {
+			output.Code = constant.OutputError
+			output.Debug = "backup - " + err.Error()
+			return output
+		}
 	}
 
 	err := file.InitXML(action, sourceFolder)
@@ -169,6 +310,9 @@ func ProcessDriverFile(file *model.DriverFile, action, sourceFolder, outputFolde
 			file.SetXML(transformedString)
 		}
 	}
+	if action == constant.Read && streamable(file) {
+		return processDriverFileReadStream(file, sourceFolder, outputFolder, environments.Source, soapFunc)
+	}
 	err = file.RunXML(action, sourceFolder, environments, soapFunc)
 	if err != nil {
 		output.Code = constant.OutputError
@@ -196,9 +340,15 @@ func ProcessDriverFile(file *model.DriverFile, action, sourceFolder, outputFolde
 	return output
 }
 
-func processMigrate(file *model.DriverFile, outputFolder string) model.Output {
+func processMigrate(file *model.DriverFile, outputFolder string, environments *model.Environments, soapFunc util.Soap) model.Output {
 	output := model.Output{Code: constant.OutputSuccess, Debug: constant.Undefined}
-	resp, err := migration.ReadDataFromExcel(file)
+	var resp string
+	var err error
+	if file.ValidateObject != constant.Undefined {
+		resp, err = validateMigration(file, outputFolder, environments, soapFunc)
+	} else {
+		resp, err = migration.ReadDataFromExcel(file)
+	}
 	if err != nil {
 		output.Code = constant.OutputError
 		output.Debug = err.Error()
@@ -214,6 +364,74 @@ func processMigrate(file *model.DriverFile, outputFolder string) model.Output {
 	return output
 }
 
+//validateMigration reads the object and its lookups from the target environment and validates the migration data.
+//When invalid values are found, the errors are saved to an excel in the output folder and no xog is created
+func validateMigration(file *model.DriverFile, outputFolder string, environments *model.Environments, soapFunc util.Soap) (string, error) {
+	if environments == nil || environments.Target == nil {
+		return constant.Undefined, errors.New("migration - validateObject requires a target environment")
+	}
+	object, err := readObjectDefinition(file.ValidateObject, environments.Target, soapFunc)
+	if err != nil {
+		return constant.Undefined, errors.New("migration - " + err.Error())
+	}
+	resp, rowErrors, err := migration.ValidateData(file, object)
+	if err != nil {
+		return constant.Undefined, err
+	}
+	if len(rowErrors) > 0 {
+		folder := outputFolder + file.Type + "/"
+		path := util.GetPathWithoutExtension(file.Path) + "_errors.xlsx"
+		err = migration.ExportErrorsToExcel(rowErrors, folder, path)
+		if err != nil {
+			return constant.Undefined, err
+		}
+		return constant.Undefined, fmt.Errorf("migration - %d invalid values, see %s", len(rowErrors), folder+path)
+	}
+	return resp, nil
+}
+
+//readObjectDefinition reads the object and the static lookups used by its attributes from the environment
+func readObjectDefinition(code string, env *model.EnvType, soapFunc util.Soap) (*migration.ObjectDefinition, error) {
+	response, err := readDefinition(constant.TypeObject, code, env, soapFunc)
+	if err != nil {
+		return nil, err
+	}
+	object, err := migration.NewObjectDefinition(response, code)
+	if err != nil {
+		return nil, err
+	}
+	for _, lookup := range object.LookupTypes() {
+		response, err := readDefinition(constant.TypeLookup, lookup, env, soapFunc)
+		if err != nil {
+			return nil, err
+		}
+		object.SetLookup(lookup, response)
+	}
+	return object, nil
+}
+
+func readDefinition(fileType, code string, env *model.EnvType, soapFunc util.Soap) (*etree.Document, error) {
+	read := &model.DriverFile{Type: fileType, Code: code, Path: code + ".xml"}
+	err := read.InitXML(constant.Read, constant.Undefined)
+	if err != nil {
+		return nil, err
+	}
+	err = read.RunXogXML(env, soapFunc)
+	if err != nil {
+		return nil, err
+	}
+	response := etree.NewDocument()
+	err = response.ReadFromString(read.GetXML())
+	if err != nil {
+		return nil, err
+	}
+	_, err = validate.Check(response)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s %s. Debug: %s", fileType, code, err.Error())
+	}
+	return response, nil
+}
+
 func processDriverFileRead(file *model.DriverFile, xogResponse *etree.Document, outputFolder string) model.Output {
 	output := model.Output{Code: constant.OutputSuccess, Debug: constant.Undefined}
 
@@ -248,7 +466,12 @@ func processDriverFileRead(file *model.DriverFile, xogResponse *etree.Document,
 	}
 
 	if file.ExportToExcel {
-		migration.ExportInstancesToExcel(xogResponse, file, constant.FolderMigration)
+		err = migration.ExportInstancesToExcel(xogResponse, file, constant.FolderMigration)
+		if err != nil {
+			output.Code = constant.OutputError
+			output.Debug = err.Error()
+			return output
+		}
 	}
 
 	return output
@@ -2,8 +2,10 @@ package xog
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/andreluzz/cas-xog/constant"
@@ -132,6 +134,77 @@ func TestLoadDriver(t *testing.T) {
 	}
 }
 
+func TestLoadDriverWithBarrierAndDependsOn(t *testing.T) {
+	total, err := LoadDriver("../mock/xog/concurrency.driver")
+	if err != nil {
+		t.Fatalf("Error loading driver. Debug: %s", err.Error())
+	}
+	if total != 5 {
+		t.Fatalf("Error loading driver expected %d and received %d", 5, total)
+	}
+
+	driver := GetLoadedDriver()
+	if driver.Concurrency != 4 {
+		t.Errorf("Error loading driver concurrency expected %d and received %d", 4, driver.Concurrency)
+	}
+	if driver.Files[2].Type != constant.TypeObject || driver.Files[3].Type != constant.TypeView {
+		t.Errorf("Error loading driver. Barrier changed the execution order")
+	}
+	if !driver.Files[3].Barrier || driver.Files[4].Barrier {
+		t.Errorf("Error loading driver. Barrier not defined to the file after the barrier tag")
+	}
+
+	expected := [][]int{{}, {}, {1}, {0, 1, 2}, {0, 1, 2}}
+	for i, e := range expected {
+		if dependencies := driver.Dependencies(i); fmt.Sprint(dependencies) != fmt.Sprint(e) {
+			t.Errorf("Error getting dependencies of file %d expected %v and received %v", i, e, dependencies)
+		}
+	}
+}
+
+func TestLoadDriverInvalidDependsOn(t *testing.T) {
+	total, err := LoadDriver("../mock/xog/invalidDependsOn.driver")
+
+	if total != 0 {
+		t.Errorf("Error loading driver expected %d and received %d", 0, total)
+	}
+
+	if err == nil {
+		t.Errorf("Error loading driver. Not catching dependsOn a file not defined before it")
+	}
+}
+
+func TestLoadDriverWithInclude(t *testing.T) {
+	total, err := LoadDriver("../mock/xog/include/include.driver")
+	if err != nil {
+		t.Fatalf("Error loading driver with include. Debug: %s", err.Error())
+	}
+	if total != 7 {
+		t.Fatalf("Error loading driver with include expected %d and received %d", 7, total)
+	}
+
+	expected := []string{"lookup_1.xml", "lookup_2.xml", "object.xml", "lookup_1.xml", "lookup_2.xml", "views.xml", "portlet_1.xml"}
+	for i, f := range GetLoadedDriver().Files {
+		if f.Path != expected[i] {
+			t.Errorf("Error loading driver with include. Expected file %s at position %d received %s", expected[i], i, f.Path)
+		}
+	}
+}
+
+func TestLoadDriverWithIncludeCycle(t *testing.T) {
+	total, err := LoadDriver("../mock/xog/include/cycleA.driver")
+
+	if total != 0 {
+		t.Errorf("Error loading driver expected %d and received %d", 0, total)
+	}
+	if err == nil {
+		t.Fatalf("Error loading driver. Not catching include cycle")
+	}
+	if !strings.Contains(err.Error(), "cycleA.driver -> ../mock/xog/include/common/cycleB.driver -> ../mock/xog/include/cycleA.driver") {
+		t.Errorf("Error loading driver. Include cycle without the include chain: %s", err.Error())
+	}
+}
+
 func TestLoadDriverInvalidVersion(t *testing.T) {
 	total, err := LoadDriver("../mock/xog/invalidVersion.driver")
 
@@ -168,6 +241,18 @@ func TestLoadDriverInvalidTagFile(t *testing.T) {
 	}
 }
 
+func TestLoadDriverInvalidXML(t *testing.T) {
+	total, err := LoadDriver("../mock/xog/invalidXML.driver")
+
+	if total != 0 {
+		t.Errorf("Error loading driver expected %d and received %d", 0, total)
+	}
+
+	if err == nil {
+		t.Errorf("Error loading driver. Not catching error with malformed xml")
+	}
+}
+
 func TestLoadDriverInvalidPath(t *testing.T) {
 	total, err := LoadDriver("")
 
@@ -199,7 +284,7 @@ func TestProcessDriverFileWrite(t *testing.T) {
 		},
 	}
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_success_write_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -216,6 +301,194 @@ func TestProcessDriverFileWrite(t *testing.T) {
 
 }
 
+func TestProcessDriverFileWriteDryRun(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	LoadDriver("../mock/xog/xog.driver")
+	file := GetLoadedDriver().Files[17]
+
+	mockEnvironments := &model.Environments{
+		Target: &model.EnvType{
+			Name:    "Mock Target Env",
+			URL:     "Mock URL",
+			Session: "Mock session",
+		},
+	}
+
+	sourceFolder := "../mock/xog/soap/"
+	util.ValidateFolder(sourceFolder + file.Type)
+	outputFolder := constant.FolderDebug
+	util.ValidateFolder(outputFolder + file.Type)
+	dryRunPath := constant.FolderDryRun + file.Type + "/" + file.Path
+	defer os.RemoveAll(constant.FolderDryRun)
+
+	output := ProcessDriverFile(&file, constant.Write, sourceFolder, outputFolder, mockEnvironments, util.DryRunSoap(dryRunPath))
+	if output.Code != constant.OutputSuccess {
+		t.Fatalf("Error processing driver file in dry-run. Debug: %s", output.Debug)
+	}
+
+	data, err := ioutil.ReadFile(dryRunPath)
+	if err != nil {
+		t.Fatalf("Error processing driver file in dry-run. Request envelope not saved. Debug: %s", err.Error())
+	}
+	request := string(data)
+	if !strings.Contains(request, "<xog:SessionID>Mock session</xog:SessionID>") || !strings.Contains(request, "<NikuDataBus") {
+		t.Errorf("Error processing driver file in dry-run. Saved request is not the soap envelope")
+	}
+}
+
+func TestProcessDriverFileWriteRetryTransientError(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	LoadDriver("../mock/xog/xog.driver")
+	file := GetLoadedDriver().Files[17]
+
+	mockEnvironments := &model.Environments{
+		Target: &model.EnvType{
+			Name:    "Mock Target Env",
+			URL:     "Mock URL",
+			Session: "Mock session",
+			Retry:   &model.RetryPolicy{Attempts: 3, Backoff: "1ms"},
+		},
+	}
+
+	calls := 0
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", &util.StatusError{StatusCode: 503, Body: "Service Unavailable"}
+		}
+		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_success_write_response.xml")
+		return util.BytesToString(file), nil
+	}
+
+	sourceFolder := "../mock/xog/soap/"
+	util.ValidateFolder(sourceFolder + file.Type)
+	outputFolder := constant.FolderDebug
+	util.ValidateFolder(outputFolder + file.Type)
+
+	output := ProcessDriverFile(&file, constant.Write, sourceFolder, outputFolder, mockEnvironments, soapMock)
+	if output.Code != constant.OutputSuccess {
+		t.Errorf("Error processing driver file with retry. Debug: %s", output.Debug)
+	}
+	if calls != 3 {
+		t.Errorf("Error processing driver file with retry. Expected 3 calls received %d", calls)
+	}
+
+	calls = 0
+	soapMock = func(request, endpoint, proxy string) (string, error) {
+		calls++
+		return "", &util.StatusError{StatusCode: 400, Body: "Bad Request"}
+	}
+	output = ProcessDriverFile(&file, constant.Write, sourceFolder, outputFolder, mockEnvironments, soapMock)
+	if output.Code != constant.OutputError {
+		t.Errorf("Error processing driver file with retry. Not returning error after non retryable status")
+	}
+	if calls != 1 {
+		t.Errorf("Error processing driver file with retry. Non retryable status executed %d calls", calls)
+	}
+}
+
+func TestProcessDriverFileWriteSessionExpired(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+	LoadDriver("../mock/xog/xog.driver")
+	file := GetLoadedDriver().Files[17]
+
+	mockEnvironments := &model.Environments{
+		Target: &model.EnvType{
+			Name:     "Mock Target Env",
+			URL:      "Mock URL",
+			Username: "Mock user",
+			Password: "Mock password",
+			Session:  "Mock session",
+		},
+	}
+
+	logins := 0
+	writes := []string{}
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		if strings.Contains(request, "<obj:Login>") {
+			logins++
+			file, _ := ioutil.ReadFile("../mock/xog/soap/soap_login_response.xml")
+			return util.BytesToString(file), nil
+		}
+		writes = append(writes, request)
+		if strings.Contains(request, "<xog:SessionID>Mock session</xog:SessionID>") {
+			file, _ := ioutil.ReadFile("../mock/xog/soap/soap_session_expired_response.xml")
+			return util.BytesToString(file), nil
+		}
+		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_success_write_response.xml")
+		return util.BytesToString(file), nil
+	}
+
+	sourceFolder := "../mock/xog/soap/"
+	util.ValidateFolder(sourceFolder + file.Type)
+	outputFolder := constant.FolderDebug
+	util.ValidateFolder(outputFolder + file.Type)
+
+	output := ProcessDriverFile(&file, constant.Write, sourceFolder, outputFolder, mockEnvironments, soapMock)
+	if output.Code != constant.OutputSuccess {
+		t.Errorf("Error processing driver file with expired session. Debug: %s", output.Debug)
+	}
+	if logins != 1 || len(writes) != 2 {
+		t.Errorf("Error processing driver file with expired session. Expected 1 login and 2 writes received %d and %d", logins, len(writes))
+	}
+	if len(writes) == 2 && !strings.Contains(writes[1], "<xog:SessionID>New mock session</xog:SessionID>") {
+		t.Errorf("Error processing driver file with expired session. Request not replayed with the new session id")
+	}
+	if mockEnvironments.Target.Session != "New mock session" {
+		t.Errorf("Error processing driver file with expired session. Environment session not updated")
+	}
+}
+
+func TestProcessDriverFileWriteSessionExpiredOnlyOnce(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+	LoadDriver("../mock/xog/xog.driver")
+	file := GetLoadedDriver().Files[17]
+
+	mockEnvironments := &model.Environments{
+		Target: &model.EnvType{
+			Name:     "Mock Target Env",
+			URL:      "Mock URL",
+			Username: "Mock user",
+			Password: "Mock password",
+			Session:  "Mock session",
+		},
+	}
+
+	logins := 0
+	writes := 0
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		if strings.Contains(request, "<obj:Login>") {
+			logins++
+			file, _ := ioutil.ReadFile("../mock/xog/soap/soap_login_response.xml")
+			return util.BytesToString(file), nil
+		}
+		writes++
+		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_session_expired_response.xml")
+		return util.BytesToString(file), nil
+	}
+
+	sourceFolder := "../mock/xog/soap/"
+	util.ValidateFolder(sourceFolder + file.Type)
+	outputFolder := constant.FolderDebug
+	util.ValidateFolder(outputFolder + file.Type)
+
+	output := ProcessDriverFile(&file, constant.Write, sourceFolder, outputFolder, mockEnvironments, soapMock)
+	if output.Code != constant.OutputError {
+		t.Errorf("Error processing driver file with expired session. Not returning error when the replay fails")
+	}
+	if logins != 1 || writes != 2 {
+		t.Errorf("Error processing driver file with expired session. Expected 1 login and 2 writes received %d and %d", logins, writes)
+	}
+}
+
 func TestProcessDriverFileActionReadSplitFiles(t *testing.T) {
 	model.LoadXMLReadList("../xogRead.xml")
 
@@ -239,7 +512,7 @@ func TestProcessDriverFileActionReadSplitFiles(t *testing.T) {
 		},
 	}
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_read_resources_instance_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -326,7 +599,7 @@ func TestProcessDriverFileActionExportToExcel(t *testing.T) {
 		},
 	}
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_read_resources_instance_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -418,6 +691,74 @@ func TestProcessDriverFileActionMigrate(t *testing.T) {
 	}
 }
 
+func TestProcessDriverFileActionMigrateWithValidateObject(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+	defer os.RemoveAll(constant.FolderMigration)
+
+	packageMockFolder := "../" + constant.FolderMock + "migration/"
+	file := model.DriverFile{
+		Type:           constant.TypeMigration,
+		Path:           "validate_test.xml",
+		Template:       packageMockFolder + "template.xml",
+		ExcelFile:      packageMockFolder + "data.csv",
+		InstanceTag:    "instance",
+		ExcelStartRow:  "1",
+		ValidateObject: "obj_sistema",
+		MatchExcel: []model.MatchExcel{
+			{Col: 1, AttributeName: "instanceCode"},
+			{Col: 1, XPath: "//ColumnValue[@name='code']"},
+			{Col: 2, XPath: "//ColumnValue[@name='name']"},
+			{Col: 3, XPath: "//ColumnValue[@name='status_novo']", RemoveIfNull: true},
+			{Col: 4, XPath: "//ColumnValue[@name='multivalue_status']", MultiValued: true, Separator: ";"},
+			{Col: 5, XPath: "//ColumnValue[@name='analista']"},
+		},
+	}
+
+	output := ProcessDriverFile(&file, constant.Migrate, "", constant.FolderMigration, nil, nil)
+	if output.Code != constant.OutputError {
+		t.Errorf("Error processing driver file. Action migrate with validateObject not requiring the target environment")
+	}
+
+	soapMock := func(request, endpoint, proxy string) (string, error) {
+		mock := "object.xml"
+		if strings.Contains(request, "<LookupQuery>") {
+			mock = "lookup.xml"
+		}
+		data, _ := ioutil.ReadFile(packageMockFolder + mock)
+		return string(data), nil
+	}
+	environments := &model.Environments{
+		Target: &model.EnvType{Name: "DEV", URL: "Mock URL", Session: "Mock session"},
+	}
+
+	output = ProcessDriverFile(&file, constant.Migrate, "", constant.FolderMigration, environments, soapMock)
+	if output.Code != constant.OutputError || !strings.Contains(output.Debug, "3 invalid values") {
+		t.Errorf("Error processing driver file. Action migrate with validateObject not returning the invalid values. Debug: %s", output.Debug)
+	}
+	folder := constant.FolderMigration + constant.TypeMigration + "/"
+	if _, err := os.Stat(folder + "validate_test_errors.xlsx"); os.IsNotExist(err) {
+		t.Errorf("Error processing driver file. Action migrate with validateObject not creating the errors excel")
+	}
+	if _, err := os.Stat(folder + "validate_test.xml"); !os.IsNotExist(err) {
+		t.Errorf("Error processing driver file. Action migrate with validateObject creating the xog with invalid values")
+	}
+
+	file.MatchExcel = file.MatchExcel[:3]
+	output = ProcessDriverFile(&file, constant.Migrate, "", constant.FolderMigration, environments, soapMock)
+	if output.Code != constant.OutputError {
+		t.Errorf("Error processing driver file. Action migrate with validateObject not validating required attributes")
+	}
+
+	file.MatchExcel = append(file.MatchExcel, model.MatchExcel{Col: 3, XPath: "//ColumnValue[@name='status_novo']", Default: "NEW"})
+	output = ProcessDriverFile(&file, constant.Migrate, "", constant.FolderMigration, environments, soapMock)
+	if output.Code != constant.OutputSuccess {
+		t.Errorf("Error processing driver file. Action migrate with validateObject and valid values. Debug: %s", output.Debug)
+	}
+	if _, err := os.Stat(folder + "validate_test.xml"); os.IsNotExist(err) {
+		t.Errorf("Error processing driver file. Action migrate with validateObject not creating the xog")
+	}
+}
+
 func TestProcessDriverFileReturnInitXMLError(t *testing.T) {
 	model.LoadXMLReadList("../xogRead.xml")
 
@@ -439,7 +780,7 @@ func TestProcessDriverFileReturnRunXMLError(t *testing.T) {
 		Path: "test.xml",
 	}
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		return "", errors.New("soap mock error")
 	}
 
@@ -463,7 +804,7 @@ func TestProcessDriverFileReturnValidateXMLError(t *testing.T) {
 		Path: "test.xml",
 	}
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		return "", nil
 	}
 
@@ -522,7 +863,7 @@ func TestProcessDriverFileActionRead(t *testing.T) {
 	outputFolder := constant.FolderDebug
 	util.ValidateFolder(outputFolder + file.Type)
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_success_read_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -561,7 +902,7 @@ func TestProcessDriverFileActionReadNeedAux(t *testing.T) {
 	outputFolder := constant.FolderDebug
 	util.ValidateFolder(outputFolder + file.Type)
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_success_read_process_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -600,7 +941,7 @@ func TestProcessDriverFileActionReadNeedAuxErrorInvalidCheck(t *testing.T) {
 	outputFolder := constant.FolderDebug
 	util.ValidateFolder(outputFolder + file.Type)
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		if endpoint == "Aux_Mock_URL" {
 			return "", nil
 		}
@@ -642,7 +983,7 @@ func TestProcessDriverFileActionReadAuxValidateError(t *testing.T) {
 	outputFolder := constant.FolderDebug
 	util.ValidateFolder(outputFolder + file.Type)
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_read_process_no_output_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -677,7 +1018,7 @@ func TestProcessDriverFileActionReadTransformError(t *testing.T) {
 	outputFolder := constant.FolderDebug
 	util.ValidateFolder(outputFolder + file.Type)
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		return `<XOGOutput>
         	<Object type="contentPack"/>
         	<Status elapsedTime="0.789 seconds" state="SUCCESS"/>
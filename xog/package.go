@@ -2,6 +2,7 @@ package xog
 
 import (
 	"encoding/xml"
+	"fmt"
 	"github.com/andreluzz/cas-xog/constant"
 	"github.com/andreluzz/cas-xog/model"
 	"github.com/andreluzz/cas-xog/transform"
@@ -11,6 +12,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -105,3 +107,89 @@ func InstallPackageFile(file *model.DriverFile, environments *model.Environments
 	file.Write(constant.FolderDebug)
 	return output
 }
+
+var packageFolderRegexp = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+//NewPackage creates the package manifest with one version and the definitions asked during the install: the partition model, the partition and a replaceString for each string in replaces
+func NewPackage(name, versionName, partitionModel, partition string, replaces []string) *model.Package {
+	version := model.Version{Name: versionName, Folder: packageFolderName(versionName)}
+	if partitionModel != constant.Undefined {
+		version.Definitions = append(version.Definitions, model.Definition{Action: constant.PackageActionChangePartitionModel, Description: "Target partition model", Default: partitionModel})
+	}
+	if partition != constant.Undefined {
+		version.Definitions = append(version.Definitions, model.Definition{Action: constant.PackageActionChangePartition, Description: "Target partition", Default: partition})
+	}
+	for _, r := range replaces {
+		version.Definitions = append(version.Definitions, model.Definition{Action: constant.PackageActionReplaceString, Description: "Replace " + r, Default: r, From: r, To: "##DEFINITION_VALUE##"})
+	}
+	return &model.Package{Name: name, Folder: packageFolderName(name), Versions: []model.Version{version}}
+}
+
+//packageFolderName returns the name in lower case without spaces and special characters, ending with a slash
+func packageFolderName(name string) string {
+	return strings.Trim(packageFolderRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-") + "/"
+}
+
+//CreatePackage creates a zip in the outputFolder with the package manifest, the driver and the files of the driver from the writeFolder,
+//using the same folders structure expected by the package install. Returns the path of the zip created
+func CreatePackage(driver *model.Driver, pkg *model.Package, writeFolder, outputFolder string) (string, error) {
+	if driver == nil || len(driver.Files) == 0 {
+		return constant.Undefined, fmt.Errorf("no driver loaded to create the package")
+	}
+	if len(pkg.Versions) == 0 {
+		return constant.Undefined, fmt.Errorf("package %s has no version", pkg.Name)
+	}
+
+	pkg.DriverFileName = filepath.Base(driver.FilePath)
+	files := map[string][]byte{}
+
+	packageDriver, err := createPackageDriver(driver)
+	if err != nil {
+		return constant.Undefined, err
+	}
+	files[pkg.Folder+pkg.DriverFileName] = packageDriver
+
+	manifest, err := xml.MarshalIndent(pkg, "", "    ")
+	if err != nil {
+		return constant.Undefined, err
+	}
+	files[pkg.Folder+strings.TrimSuffix(pkg.Folder, "/")+".package"] = append([]byte(xml.Header), manifest...)
+
+	for _, f := range driver.Files {
+		if f.Type == constant.TypeMigration {
+			return constant.Undefined, fmt.Errorf("tag <%s> cannot be included in a package", f.GetXMLType())
+		}
+		writePath := f.Path
+		if f.GetInstanceTag() != constant.Undefined && f.InstancesPerFile > 0 {
+			writePath = "complete_" + util.GetPathWithoutExtension(f.Path) + ".xml"
+		}
+		xog, err := ioutil.ReadFile(writeFolder + f.Type + "/" + writePath)
+		if err != nil {
+			return constant.Undefined, fmt.Errorf("write file of %s %s not found, execute the action r before creating the package - %s", f.GetXMLType(), f.Path, err.Error())
+		}
+		for _, v := range pkg.Versions {
+			files[pkg.Folder+v.Folder+f.Type+"/"+f.Path] = xog
+		}
+	}
+
+	zipPath := outputFolder + strings.TrimSuffix(pkg.Folder, "/") + ".zip"
+	err = util.Zip(files, zipPath)
+	if err != nil {
+		return constant.Undefined, fmt.Errorf("error creating package zip - %s", err.Error())
+	}
+	return zipPath, nil
+}
+
+//createPackageDriver returns the driver with the includes and variables resolved, so the package does not depend on other drivers or environments
+func createPackageDriver(driver *model.Driver) ([]byte, error) {
+	doc := etree.NewDocument()
+	err := doc.ReadFromBytes(driver.XML)
+	if err != nil {
+		return nil, fmt.Errorf("invalid driver(%s) - %s", driver.FilePath, err.Error())
+	}
+	for _, v := range doc.FindElements("//xogdriver/vars") {
+		doc.Root().RemoveChild(v)
+	}
+	doc.Root().RemoveAttr("autoWrite")
+	return doc.WriteToBytes()
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -103,7 +104,7 @@ func TestProcessAndTransformPackageFile(t *testing.T) {
 	packageFolder := folder + selectedPackage.Folder + selectedPackage.Versions[0].Folder + file.Type + "/"
 	writeFolder := constant.FolderWrite + file.Type
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/package_transform_view_target.xml")
 		return util.BytesToString(file), nil
 	}
@@ -161,7 +162,7 @@ func TestInstallPackageFile(t *testing.T) {
 		},
 	}
 
-	soapMock := func(request, endpoint string) (string, error) {
+	soapMock := func(request, endpoint, proxy string) (string, error) {
 		file, _ := ioutil.ReadFile("../mock/xog/soap/soap_success_write_response.xml")
 		return util.BytesToString(file), nil
 	}
@@ -171,7 +172,7 @@ func TestInstallPackageFile(t *testing.T) {
 		t.Errorf("Error installing package file. Debug: %s", output.Debug)
 	}
 
-	soapMock = func(request, endpoint string) (string, error) {
+	soapMock = func(request, endpoint, proxy string) (string, error) {
 		return "", nil
 	}
 
@@ -182,3 +183,70 @@ func TestInstallPackageFile(t *testing.T) {
 
 	deleteTestFolders()
 }
+
+func TestCreatePackage(t *testing.T) {
+	folder := "../mock/xog/package/" + constant.FolderPackage
+	output := "../mock/xog/package/created/"
+	defer os.RemoveAll(folder)
+	defer os.RemoveAll(output)
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+
+	LoadDriver("../mock/xog/package/create.driver")
+	pkg := NewPackage("Mock Created Package", "Version Oracle", "corporate", "NIKU.ROOT", []string{"xogadmin"})
+	zipPath, err := CreatePackage(GetLoadedDriver(), pkg, "../mock/xog/package/write/", output)
+	if err != nil {
+		t.Fatalf("Error creating package. Debug: %s", err.Error())
+	}
+	if zipPath != output+"mock-created-package.zip" {
+		t.Errorf("Error creating package, expected zip %s received %s", output+"mock-created-package.zip", zipPath)
+	}
+
+	LoadPackages(folder, output)
+	packages := GetAvailablePackages()
+	if len(packages) != 1 {
+		t.Fatalf("Error loading created package, expected 1 package received %d", len(packages))
+	}
+	p := packages[0]
+	if p.Name != "Mock Created Package" || p.Folder != "mock-created-package/" || p.DriverFileName != "create.driver" {
+		t.Errorf("Error loading created package manifest, received %+v", p)
+	}
+	if len(p.Versions) != 1 || p.Versions[0].Folder != "version-oracle/" || len(p.Versions[0].Definitions) != 3 {
+		t.Fatalf("Error loading created package version, received %+v", p.Versions)
+	}
+	replace := p.Versions[0].Definitions[2]
+	if replace.Action != constant.PackageActionReplaceString || replace.From != "xogadmin" || replace.To != "##DEFINITION_VALUE##" {
+		t.Errorf("Error loading created package replaceString definition, received %+v", replace)
+	}
+
+	for _, path := range []string{"Lookups/OBJ_SISTEMA_STATUS.xml", "Objects/obj_sistema.xml", "CustomObjectInstances/instances.xml"} {
+		if _, err := os.Stat(folder + p.Folder + p.Versions[0].Folder + path); err != nil {
+			t.Errorf("Error creating package, file %s not found", path)
+		}
+	}
+	instances, _ := ioutil.ReadFile(folder + p.Folder + p.Versions[0].Folder + "CustomObjectInstances/instances.xml")
+	if !strings.Contains(string(instances), "complete_instances") {
+		t.Errorf("Error creating package, expected the complete write file of split instances")
+	}
+
+	total, err := LoadDriver(folder + p.Folder + p.DriverFileName)
+	if err != nil || total != 3 {
+		t.Fatalf("Error loading created package driver, expected 3 files received %d. Debug: %v", total, err)
+	}
+	if GetLoadedDriver().AutomaticWrite || GetLoadedDriver().Files[1].Code != "obj_sistema" {
+		t.Errorf("Error creating package driver, expected variables resolved and autoWrite removed")
+	}
+}
+
+func TestCreatePackageMissingWriteFile(t *testing.T) {
+	output := "../mock/xog/package/created/"
+	defer os.RemoveAll(output)
+	loaded := driverXOG
+	defer func() { driverXOG = loaded }()
+
+	LoadDriver("../mock/xog/package/create.driver")
+	_, err := CreatePackage(GetLoadedDriver(), NewPackage("Mock", "Default", "", "", nil), "../mock/xog/package/", output)
+	if err == nil {
+		t.Errorf("Error creating package, not catching error when the write files were not created")
+	}
+}
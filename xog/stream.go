@@ -0,0 +1,379 @@
+package xog
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/transform"
+	"github.com/andreluzz/cas-xog/util"
+	"github.com/andreluzz/cas-xog/validate"
+	"github.com/beevik/etree"
+)
+
+//completeInstancesMarker is the tag replaced by the instances when writing the complete file of a streamed read
+const completeInstancesMarker = "casxogInstances"
+
+var nikuDataBusRegexp = regexp.MustCompile("(?s)<NikuDataBus(.*)</NikuDataBus>")
+
+//streamable validates if the file is read in chunks: instances with the attribute stream split by instancesPerFile whose transforms do not depend on the other instances
+func streamable(file *model.DriverFile) bool {
+	if !file.Stream {
+		return false
+	}
+	switch file.Type {
+	case constant.TypeMigration, constant.TypeOBSInstance, constant.TypeDepartmentInstance:
+		return false
+	}
+	return file.GetInstanceTag() != constant.Undefined && file.InstancesPerFile > 0 && !file.ExportToExcel && !file.NeedAuxXML()
+}
+
+//instanceChunk is a sequence of instances of the streamed response, stored as their offsets in the response file
+type instanceChunk [][2]int64
+
+//streamedRead splits the response saved to disk into files with instancesPerFile instances, without loading the whole response in memory
+type streamedRead struct {
+	file         *model.DriverFile
+	response     *os.File
+	outputFolder string
+	originalPath string
+	path         string
+	prefix       []byte
+	suffix       string
+	total        int
+	written      []string
+	complete     *os.File
+	completeTail string
+	indent       string
+}
+
+//processDriverFileReadStream reads the file writing the xog response to disk. The instances are split into files of instancesPerFile as they are found,
+//each split file is transformed on its own and the XOGOutput is validated without parsing the whole response
+func processDriverFileReadStream(file *model.DriverFile, sourceFolder, outputFolder string, env *model.EnvType, soapFunc util.Soap) model.Output {
+	file.Write(sourceFolder)
+
+	path := util.GetPathWithoutExtension(file.Path)
+	responsePath := outputFolder + file.Type + "/" + path + ".response"
+	defer os.Remove(responsePath)
+
+	err := file.RunXogXMLToFile(env, util.SoapStreamOf(soapFunc), responsePath)
+	if err != nil {
+		return model.Output{Code: constant.OutputError, Debug: err.Error()}
+	}
+
+	response, err := os.Open(responsePath)
+	if err != nil {
+		return model.Output{Code: constant.OutputError, Debug: err.Error()}
+	}
+	defer response.Close()
+
+	s := &streamedRead{file: file, response: response, outputFolder: outputFolder, originalPath: file.Path, path: path}
+	xogOutput, err := s.split()
+	closeErr := s.closeComplete()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && s.total == 0 {
+		//without instances the response is small, it is processed in memory as the other reads
+		return s.processInMemory()
+	}
+
+	output := model.Output{Code: constant.OutputError, Debug: "no output tag defined"}
+	if err == nil && xogOutput != nil {
+		output, err = validate.Check(xogOutput)
+	}
+	if err != nil {
+		s.remove()
+		output.Code = constant.OutputError
+		output.Debug = err.Error()
+		s.writeDebug(xogOutput)
+		return output
+	}
+
+	file.Path = "complete_" + path + ".xml"
+	return output
+}
+
+//split reads the response tokens and writes a file each time instancesPerFile instances are found. Returns the XOGOutput element found in the response
+func (s *streamedRead) split() (*etree.Document, error) {
+	instanceTag := s.file.GetInstanceTag()
+	decoder := xml.NewDecoder(bufio.NewReader(s.response))
+
+	stack := []string{}
+	parentDepth := -1
+	parentName := constant.Undefined
+	chunk := instanceChunk{}
+	var xogOutput *etree.Document
+
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return xogOutput, errors.New("invalid xog response - " + err.Error())
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == instanceTag && parentDepth < 0 && len(stack) > 0 {
+				parentDepth = len(stack)
+				parentName = stack[len(stack)-1]
+				err = s.setEnvelope(start, stack)
+				if err != nil {
+					return xogOutput, err
+				}
+			}
+			if t.Name.Local == instanceTag && len(stack) == parentDepth && stack[len(stack)-1] == parentName {
+				end, err := skipElement(decoder)
+				if err != nil {
+					return xogOutput, err
+				}
+				chunk = append(chunk, [2]int64{start, end})
+				if len(chunk) == s.file.InstancesPerFile {
+					err = s.writeChunk(chunk)
+					if err != nil {
+						return xogOutput, err
+					}
+					chunk = instanceChunk{}
+				}
+				continue
+			}
+			if t.Name.Local == "XOGOutput" {
+				end, err := skipElement(decoder)
+				if err != nil {
+					return xogOutput, err
+				}
+				xogOutput, err = s.readDocument(start, end)
+				if err != nil {
+					return xogOutput, err
+				}
+				continue
+			}
+			stack = append(stack, rawName(t.Name))
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if len(chunk) > 0 {
+		return xogOutput, s.writeChunk(chunk)
+	}
+	return xogOutput, nil
+}
+
+//setEnvelope stores the content before the first instance and the tags closing its ancestors, used to create each split file
+func (s *streamedRead) setEnvelope(firstInstance int64, stack []string) error {
+	s.prefix = make([]byte, firstInstance)
+	_, err := s.response.ReadAt(s.prefix, 0)
+	if err != nil {
+		return err
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		s.suffix += "</" + stack[i] + ">"
+	}
+	return nil
+}
+
+//writeChunk transforms the instances of the chunk as a xog document and saves it as a split file. The instances are appended to the complete file
+func (s *streamedRead) writeChunk(chunk instanceChunk) error {
+	content := append([]byte{}, s.prefix...)
+	for _, c := range chunk {
+		instance := make([]byte, c[1]-c[0])
+		_, err := s.response.ReadAt(instance, c[0])
+		if err != nil {
+			return err
+		}
+		content = append(content, instance...)
+	}
+	content = append(content, s.suffix...)
+
+	xog := etree.NewDocument()
+	err := xog.ReadFromBytes(content)
+	if err != nil {
+		return errors.New("invalid xog response - " + err.Error())
+	}
+	err = transform.Execute(xog, nil, s.file)
+	if err != nil {
+		return err
+	}
+	err = s.appendComplete(xog)
+	if err != nil {
+		return err
+	}
+
+	s.total++
+	s.file.Path = fmt.Sprintf("%s_%03d.xml", s.path, s.total)
+	xog.IndentTabs()
+	str, _ := xog.WriteToString()
+	s.file.SetXML(str)
+	s.file.Write(s.outputFolder)
+	s.written = append(s.written, s.file.Path)
+	return nil
+}
+
+//appendComplete writes the instances of the transformed chunk to the complete file, creating it with the envelope of the first chunk
+func (s *streamedRead) appendComplete(xog *etree.Document) error {
+	instanceTag := s.file.GetInstanceTag()
+	first := xog.FindElement("//" + instanceTag)
+	if first == nil {
+		return nil
+	}
+	instances := first.Parent().SelectElements(instanceTag)
+
+	if s.complete == nil {
+		envelope := xog.Copy()
+		parent := envelope.FindElement("//" + instanceTag).Parent()
+		for _, e := range parent.SelectElements(instanceTag) {
+			parent.RemoveChild(e)
+		}
+		parent.CreateElement(completeInstancesMarker)
+		envelope.Indent(4)
+		str, _ := envelope.WriteToString()
+		str = nikuDataBusRegexp.FindString(str)
+		parts := strings.SplitN(str, "<"+completeInstancesMarker+"/>", 2)
+		if len(parts) != 2 {
+			return errors.New("invalid xog response - instances parent not found")
+		}
+		s.indent = parts[0][strings.LastIndex(parts[0], "\n")+1:]
+		s.completeTail = parts[1]
+
+		file, err := os.Create(s.outputFolder + s.file.Type + "/complete_" + s.path + ".xml")
+		if err != nil {
+			return err
+		}
+		s.complete = file
+		_, err = io.WriteString(file, strings.TrimSuffix(parts[0], s.indent))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range instances {
+		doc := etree.NewDocument()
+		doc.SetRoot(e.Copy())
+		doc.Indent(4)
+		str, _ := doc.WriteToString()
+		str = s.indent + strings.Replace(strings.TrimSpace(str), "\n", "\n"+s.indent, -1) + "\n"
+		_, err := io.WriteString(s.complete, str)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//closeComplete writes the end of the complete file after the last instance
+func (s *streamedRead) closeComplete() error {
+	if s.complete == nil {
+		return nil
+	}
+	_, err := io.WriteString(s.complete, strings.TrimPrefix(s.completeTail, "\n"))
+	closeErr := s.complete.Close()
+	s.complete = nil
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+//processInMemory processes a response without instances as the reads that are not streamed
+func (s *streamedRead) processInMemory() model.Output {
+	s.remove()
+	data, err := ioutil.ReadFile(s.response.Name())
+	if err != nil {
+		return model.Output{Code: constant.OutputError, Debug: err.Error()}
+	}
+	s.file.SetXML(string(data))
+	xogResponse := etree.NewDocument()
+	xogResponse.ReadFromString(s.file.GetXML())
+	output, err := validate.Check(xogResponse)
+	if err != nil {
+		output.Code = constant.OutputError
+		output.Debug = err.Error()
+		s.file.Write(constant.FolderDebug)
+		return output
+	}
+	readOutput := processDriverFileRead(s.file, xogResponse, s.outputFolder)
+	if readOutput.Code != constant.OutputSuccess {
+		s.file.Write(constant.FolderDebug)
+		return readOutput
+	}
+	s.file.Write(s.outputFolder)
+	return output
+}
+
+//remove deletes the split and complete files already written, used when the response is invalid
+func (s *streamedRead) remove() {
+	for _, p := range s.written {
+		os.Remove(s.outputFolder + s.file.Type + "/" + p)
+	}
+	os.Remove(s.outputFolder + s.file.Type + "/complete_" + s.path + ".xml")
+	s.written = nil
+	s.file.Path = s.originalPath
+}
+
+//writeDebug saves the XOGOutput of an invalid response to the debug folder, or the whole response if there is no output
+func (s *streamedRead) writeDebug(xogOutput *etree.Document) {
+	util.ValidateFolder(constant.FolderDebug + s.file.Type)
+	target := constant.FolderDebug + s.file.Type + "/" + s.file.Path
+	if xogOutput != nil {
+		xogOutput.WriteToFile(target)
+		return
+	}
+	data, err := ioutil.ReadFile(s.response.Name())
+	if err == nil {
+		ioutil.WriteFile(target, data, os.ModePerm)
+	}
+}
+
+//readDocument parses the part of the response between the offsets
+func (s *streamedRead) readDocument(start, end int64) (*etree.Document, error) {
+	data := make([]byte, end-start)
+	_, err := s.response.ReadAt(data, start)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(data)
+	if err != nil {
+		return nil, errors.New("invalid xog output - " + err.Error())
+	}
+	return doc, nil
+}
+
+//skipElement reads the tokens until the end of the current element and returns the offset after it
+func skipElement(decoder *xml.Decoder) (int64, error) {
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return 0, errors.New("invalid xog response - " + err.Error())
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return decoder.InputOffset(), nil
+}
+
+func rawName(name xml.Name) string {
+	if name.Space == constant.Undefined {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
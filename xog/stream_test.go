@@ -0,0 +1,121 @@
+package xog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/andreluzz/cas-xog/util"
+)
+
+func streamSoapMock(path string, calls *int) util.Soap {
+	return func(request, endpoint, proxy string) (string, error) {
+		*calls++
+		file, err := ioutil.ReadFile(path)
+		return util.BytesToString(file), err
+	}
+}
+
+func streamEnvironments() *model.Environments {
+	return &model.Environments{
+		Source: &model.EnvType{Name: "Mock Source Env", URL: "Mock URL", Session: "Mock session"},
+		Target: &model.EnvType{Name: "Mock Target Env", URL: "Mock URL", Session: "Mock session"},
+	}
+}
+
+func TestProcessDriverFileReadStreamSplitFiles(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	response := "../mock/xog/soap/soap_read_resources_instance_response.xml"
+	sourceFolder := "../" + constant.FolderRead
+	memoryFolder := "../" + constant.FolderDebug + "memory/"
+	streamFolder := "../" + constant.FolderDebug + "stream/"
+	defer os.RemoveAll(memoryFolder)
+	defer os.RemoveAll(streamFolder)
+
+	results := map[string]string{}
+	for folder, stream := range map[string]bool{memoryFolder: false, streamFolder: true} {
+		calls := 0
+		file := model.DriverFile{Type: constant.TypeResourceInstance, Code: "*", Path: "instances.xml", InstancesPerFile: 40, Stream: stream}
+		util.ValidateFolder(sourceFolder + file.Type)
+		util.ValidateFolder(folder + file.Type)
+
+		output := ProcessDriverFile(&file, constant.Read, sourceFolder, folder, streamEnvironments(), streamSoapMock(response, &calls))
+		if output.Code != constant.OutputSuccess {
+			t.Fatalf("Error processing driver file. Action read streaming with errors. Debug: %s", output.Debug)
+		}
+		if calls != 1 {
+			t.Errorf("Error processing driver file. Action read with stream %t called the soap function %d times", stream, calls)
+		}
+		if file.Path != "complete_instances.xml" {
+			t.Errorf("Error processing driver file. Action read streaming returned path %s", file.Path)
+		}
+		files, _ := ioutil.ReadDir(folder + file.Type)
+		for _, f := range files {
+			data, _ := ioutil.ReadFile(folder + file.Type + "/" + f.Name())
+			results[folder+f.Name()] = string(data)
+		}
+	}
+
+	total := 0
+	for name, content := range results {
+		if !strings.HasPrefix(name, streamFolder) {
+			continue
+		}
+		total++
+		fileName := strings.TrimPrefix(name, streamFolder)
+		if fileName == "complete_instances.xml" && strings.Count(content, "<Resource ") != 287 {
+			t.Errorf("Error processing driver file. Complete streamed file with %d instances", strings.Count(content, "<Resource "))
+		}
+		if content != results[memoryFolder+fileName] {
+			t.Errorf("Error processing driver file. Streamed file %s different from the file split in memory", fileName)
+		}
+	}
+	if total != 9 {
+		t.Errorf("Error processing driver file. Action read streaming expecting 9 files received %d", total)
+	}
+}
+
+func TestProcessDriverFileReadStreamInvalidOutput(t *testing.T) {
+	model.LoadXMLReadList("../xogRead.xml")
+
+	data, _ := ioutil.ReadFile("../mock/xog/soap/soap_read_resources_instance_response.xml")
+	data = bytes.Replace(data, []byte(`failureRecords="0"`), []byte(`failureRecords="3"`), 1)
+	response, _ := ioutil.TempFile("", "response*.xml")
+	response.Write(data)
+	response.Close()
+	defer os.Remove(response.Name())
+
+	file := model.DriverFile{Type: constant.TypeResourceInstance, Code: "*", Path: "instances.xml", InstancesPerFile: 40, Stream: true}
+	sourceFolder := "../" + constant.FolderRead
+	outputFolder := "../" + constant.FolderDebug + "stream/"
+	util.ValidateFolder(sourceFolder + file.Type)
+	util.ValidateFolder(outputFolder + file.Type)
+	defer os.RemoveAll(outputFolder)
+	defer os.RemoveAll(constant.FolderDebug)
+
+	calls := 0
+	output := ProcessDriverFile(&file, constant.Read, sourceFolder, outputFolder, streamEnvironments(), streamSoapMock(response.Name(), &calls))
+	if output.Code != constant.OutputError || !strings.Contains(output.Debug, "failure on 3 records") {
+		t.Errorf("Error processing driver file. Action read streaming not validating the output. Debug: %s", output.Debug)
+	}
+	files, _ := ioutil.ReadDir(outputFolder + file.Type)
+	if len(files) != 0 {
+		t.Errorf("Error processing driver file. Action read streaming kept %d files of an invalid response", len(files))
+	}
+}
+
+func TestStreamableToRequireAttribute(t *testing.T) {
+	file := model.DriverFile{Type: constant.TypeResourceInstance, Code: "*", Path: "instances.xml", InstancesPerFile: 40}
+	if streamable(&file) {
+		t.Errorf("Error validating stream. Instances read as stream without the attribute stream")
+	}
+	file.Stream = true
+	if !streamable(&file) {
+		t.Errorf("Error validating stream. Instances with the attribute stream not read as stream")
+	}
+}
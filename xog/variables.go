@@ -0,0 +1,125 @@
+package xog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andreluzz/cas-xog/constant"
+	"github.com/andreluzz/cas-xog/model"
+	"github.com/beevik/etree"
+)
+
+var variableRegexp = regexp.MustCompile(`\$?\$\{([^{}]+)\}`)
+
+//variableOverrides stores the values defined in the command line, they have precedence over environment and driver values
+var variableOverrides = map[string]string{}
+
+//driverNeedsEnvironment is true when the loaded driver uses variables defined in the environments
+var driverNeedsEnvironment bool
+
+//SetVariableOverrides defines the values that override the driver and environment variables
+func SetVariableOverrides(overrides map[string]string) {
+	variableOverrides = map[string]string{}
+	for name, value := range overrides {
+		variableOverrides[name] = value
+	}
+}
+
+//ResolveEnvironmentVariables reloads the driver replacing the variables with the values defined in the environment
+func ResolveEnvironmentVariables(env *model.EnvType) error {
+	if driverXOG == nil || !driverNeedsEnvironment {
+		return nil
+	}
+	if env == nil || env.Name == constant.Undefined {
+		return fmt.Errorf("invalid driver(%s) uses variables defined in the environments, but no environment was selected", driverXOG.FilePath)
+	}
+	_, err := loadDriver(driverXOG.FilePath, env)
+	return err
+}
+
+//replaceVariables replaces the placeholders ${name} with the values from the command line, the environment and the driver tag <vars>, in this order.
+//Without an environment, variables defined in every available environment are kept to be resolved after the environment is selected
+func replaceVariables(path string, xmlFile []byte, env *model.EnvType) ([]byte, error) {
+	driverNeedsEnvironment = false
+	if !variableRegexp.Match(xmlFile) {
+		return xmlFile, nil
+	}
+
+	driverVars := map[string]string{}
+	doc := etree.NewDocument()
+	if doc.ReadFromBytes(xmlFile) == nil {
+		for _, v := range doc.FindElements("//xogdriver/vars/var") {
+			driverVars[v.SelectAttrValue("name", constant.Undefined)] = v.SelectAttrValue("value", constant.Undefined)
+		}
+	}
+
+	unresolved := map[string]bool{}
+	result := variableRegexp.ReplaceAllFunc(xmlFile, func(match []byte) []byte {
+		if bytes.HasPrefix(match, []byte("$$")) {
+			return match[1:]
+		}
+		name := strings.TrimSpace(string(match[2 : len(match)-1]))
+		if value, ok := variableOverrides[name]; ok {
+			return escapeVariable(value)
+		}
+		if environmentVariableDefined(name, false) {
+			driverNeedsEnvironment = true
+			if env == nil {
+				if _, ok := driverVars[name]; !ok && !environmentVariableDefined(name, true) {
+					unresolved[name] = true
+				}
+				return match
+			}
+		}
+		if value, ok := env.Variable(name); ok {
+			return escapeVariable(value)
+		}
+		if value, ok := driverVars[name]; ok {
+			return escapeVariable(value)
+		}
+		unresolved[name] = true
+		return match
+	})
+
+	if len(unresolved) > 0 {
+		names := []string{}
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		envName := "every environment"
+		if env != nil {
+			envName = "environment " + env.Name
+		}
+		return nil, fmt.Errorf("invalid driver(%s) unresolved variables: %s. Define them in the driver tag <vars>, in %s or in the command line", path, strings.Join(names, ", "), envName)
+	}
+	return result, nil
+}
+
+//environmentVariableDefined validates if the variable is defined in any, or in every, available environment
+func environmentVariableDefined(name string, every bool) bool {
+	available := model.GetAvailableEnvironments()
+	if len(available) == 0 {
+		return false
+	}
+	for _, e := range available {
+		_, ok := e.Variable(name)
+		if ok && !every {
+			return true
+		}
+		if !ok && every {
+			return false
+		}
+	}
+	return every
+}
+
+func escapeVariable(value string) []byte {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(value))
+	return b.Bytes()
+}
@@ -0,0 +1,83 @@
+package xog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andreluzz/cas-xog/model"
+)
+
+func TestLoadDriverWithVariables(t *testing.T) {
+	SetVariableOverrides(map[string]string{"instance": "inst_<1>"})
+	defer SetVariableOverrides(nil)
+
+	_, err := LoadDriver("../mock/xog/variables/variables.driver")
+	if err != nil {
+		t.Fatalf("Error loading driver with variables. Debug: %s", err.Error())
+	}
+
+	files := GetLoadedDriver().Files
+	object := files[0]
+	if object.Code != "obj_sistema" || object.Path != "obj_sistema.xml" || object.SourcePartition != "NIKU.ROOT" {
+		t.Errorf("Error loading driver with variables. Attributes not replaced: code = %s path = %s partition = %s", object.Code, object.Path, object.SourcePartition)
+	}
+	if object.Replace[0].From != "${literal}" || object.Replace[0].To != "NIKU.ROOT" {
+		t.Errorf("Error loading driver with variables. Invalid replace from = %s to = %s", object.Replace[0].From, object.Replace[0].To)
+	}
+	if files[1].Filters[0].Value != "inst_<1>" {
+		t.Errorf("Error loading driver with variables. Filter not replaced by command line value: %s", files[1].Filters[0].Value)
+	}
+}
+
+func TestLoadDriverWithUnresolvedVariables(t *testing.T) {
+	_, err := LoadDriver("../mock/xog/variables/variables.driver")
+	if err == nil {
+		t.Fatalf("Error loading driver with variables. Not catching unresolved variable")
+	}
+	if !strings.Contains(err.Error(), "unresolved variables: instance") {
+		t.Errorf("Error loading driver with variables. Invalid error: %s", err.Error())
+	}
+}
+
+func TestLoadDriverWithEnvironmentVariables(t *testing.T) {
+	environments, err := model.LoadEnvironmentsList("../mock/xog/variables/xogEnv.xml")
+	if err != nil {
+		t.Fatalf("Error loading environments. Debug: %s", err.Error())
+	}
+
+	_, err = LoadDriver("../mock/xog/variables/environment.driver")
+	if err != nil {
+		t.Fatalf("Error loading driver with environment variables. Debug: %s", err.Error())
+	}
+
+	err = ResolveEnvironmentVariables(nil)
+	if err == nil {
+		t.Errorf("Error resolving environment variables. Not catching driver without environment selected")
+	}
+
+	environments.Target = environments.Available[1]
+	err = ResolveEnvironmentVariables(environments.Target)
+	if err != nil {
+		t.Fatalf("Error resolving environment variables. Debug: %s", err.Error())
+	}
+	view := GetLoadedDriver().Files[0]
+	if view.TargetPartition != "QA_PARTITION" || view.SourcePartition != "QA_ROOT" {
+		t.Errorf("Error resolving environment variables. Expected QA values received source = %s target = %s", view.SourcePartition, view.TargetPartition)
+	}
+
+	err = ResolveEnvironmentVariables(environments.Available[0])
+	if err != nil {
+		t.Fatalf("Error resolving environment variables. Debug: %s", err.Error())
+	}
+	view = GetLoadedDriver().Files[0]
+	if view.TargetPartition != "DEV_PARTITION" || view.SourcePartition != "NIKU.ROOT" {
+		t.Errorf("Error resolving environment variables. Expected DEV values received source = %s target = %s", view.SourcePartition, view.TargetPartition)
+	}
+
+	environments, _ = model.LoadEnvironmentsList("../mock/xog/variables/xogEnv.xml")
+	environments.Available[0].Vars = nil
+	_, err = LoadDriver("../mock/xog/variables/environment.driver")
+	if err == nil || !strings.Contains(err.Error(), "targetPartition") {
+		t.Errorf("Error loading driver with environment variables. Not catching variable missing in one environment")
+	}
+}